@@ -9,9 +9,13 @@ import (
 )
 
 func GenerateHashFromString(str string) string {
-	hash := sha256.New()
-	hash.Write([]byte(str))
-	return hex.EncodeToString(hash.Sum(nil))
+	return GenerateHash([]byte(str))
+}
+
+// GenerateHash returns hex encoded SHA-256 sum of data
+func GenerateHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
 func GenerateSaltedHash(str string, salt string) string {
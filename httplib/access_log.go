@@ -0,0 +1,78 @@
+package httplib
+
+import (
+	"github.com/rovergulf/utils/ipaddr"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// AccessLogConfig controls which requests are written to the access log
+type AccessLogConfig struct {
+	// SkipPaths are not logged unless response status is 5xx, e.g. health checks
+	SkipPaths []string
+	// SampleRate is a fraction of non-error requests to log, zero value logs every request
+	SampleRate float64
+}
+
+func (c AccessLogConfig) shouldLog(r *http.Request, status int) bool {
+	if status >= http.StatusInternalServerError {
+		return true
+	}
+
+	for _, p := range c.SkipPaths {
+		if p == r.URL.Path {
+			return false
+		}
+	}
+
+	if status >= http.StatusBadRequest {
+		return true
+	}
+
+	if c.SampleRate > 0 && c.SampleRate < 1 {
+		return rand.Float64() < c.SampleRate
+	}
+
+	return true
+}
+
+// LoggingMiddleware writes single access log entry for every request after it is handled
+func (i *Interceptor) LoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i.Logger == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rw, ww := wrapResponseWriter(w)
+		route := i.requestRoute(r)
+
+		next.ServeHTTP(ww, r)
+
+		status := rw.Status()
+		if !i.AccessLog.shouldLog(r, status) {
+			return
+		}
+
+		fields := []interface{}{
+			"request_id", RequestIDFromContext(r.Context()),
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", route,
+			"query", r.URL.RawQuery,
+			"status", status,
+			"bytes", rw.Size(),
+			"latency", time.Since(start),
+			"client_ip", ipaddr.GetRequestIPAddress(r),
+			"user_agent", r.UserAgent(),
+		}
+
+		if status >= http.StatusInternalServerError {
+			i.Logger.Errorw("Request handled", fields...)
+		} else {
+			i.Logger.Infow("Request handled", fields...)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package httplib
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessLogConfig_shouldLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   AccessLogConfig
+		path   string
+		status int
+		want   bool
+	}{
+		{"default", AccessLogConfig{}, "/users", http.StatusOK, true},
+		{"skipped path", AccessLogConfig{SkipPaths: []string{"/healthz"}}, "/healthz", http.StatusOK, false},
+		{"skipped path client error", AccessLogConfig{SkipPaths: []string{"/healthz"}}, "/healthz", http.StatusNotFound, false},
+		{"skipped path server error", AccessLogConfig{SkipPaths: []string{"/healthz"}}, "/healthz", http.StatusServiceUnavailable, true},
+		{"not skipped path", AccessLogConfig{SkipPaths: []string{"/healthz"}}, "/healthz/deep", http.StatusOK, true},
+		{"full sample rate", AccessLogConfig{SampleRate: 1}, "/users", http.StatusOK, true},
+		{"sampled out client error", AccessLogConfig{SampleRate: 0.000001}, "/users", http.StatusBadRequest, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if got := tt.conf.shouldLog(r, tt.status); got != tt.want {
+				t.Errorf("shouldLog() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessLogConfig_sampling(t *testing.T) {
+	conf := AccessLogConfig{SampleRate: 0.1}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	var logged int
+	for i := 0; i < 10000; i++ {
+		if conf.shouldLog(r, http.StatusOK) {
+			logged++
+		}
+	}
+
+	// expected 1000 with deviation about 30
+	if logged < 800 || logged > 1200 {
+		t.Errorf("logged %d of 10000 requests with 0.1 sample rate", logged)
+	}
+}
+
+func TestInterceptor_LoggingMiddleware(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	i := &Interceptor{
+		Logger:    zap.New(core).Sugar(),
+		AccessLog: AccessLogConfig{SkipPaths: []string{"/healthz"}},
+	}
+
+	handler := i.LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	for _, path := range []string{"/users", "/healthz", "/fail"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("logged %d entries, want 2", len(entries))
+	}
+
+	tests := []struct {
+		path   string
+		status int64
+		level  zapcore.Level
+	}{
+		{"/users", http.StatusOK, zapcore.InfoLevel},
+		{"/fail", http.StatusBadGateway, zapcore.ErrorLevel},
+	}
+	for idx, tt := range tests {
+		fields := entries[idx].ContextMap()
+		if fields["path"] != tt.path || fields["status"] != tt.status || fields["bytes"] != int64(5) || entries[idx].Level != tt.level {
+			t.Errorf("entry %d = %s %v, want %s status %d 5 bytes at %s", idx, entries[idx].Level, fields, tt.path, tt.status, tt.level)
+		}
+	}
+}
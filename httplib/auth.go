@@ -0,0 +1,168 @@
+package httplib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrTokenExpired = fmt.Errorf("httplib: token is expired")
+	ErrEmptySubject = fmt.Errorf("httplib: token subject is empty")
+)
+
+// Principal is an authenticated subject of the request
+type Principal struct {
+	Subject string
+	// Claims contains authenticator specific token data
+	Claims interface{}
+}
+
+// Authenticator validates token and returns its principal
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// AuthenticatorFunc is an adapter to use ordinary function as Authenticator
+type AuthenticatorFunc func(ctx context.Context, token string) (*Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	return f(ctx, token)
+}
+
+// ContextWithPrincipal returns a copy of ctx holding specified principal
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext returns principal stored by AuthMiddleware, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok
+}
+
+// AuthConfig configures AuthMiddleware
+type AuthConfig struct {
+	Authenticator Authenticator
+	TokenExtractor
+	// Realm is sent in WWW-Authenticate header
+	Realm string
+	// Optional lets requests without token through, without principal in context
+	Optional bool
+}
+
+// AuthMiddleware authenticates request token and stores principal in the request context.
+// Unauthenticated requests are rejected with 401 ApiError and WWW-Authenticate header.
+func AuthMiddleware(conf AuthConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := conf.Extract(r)
+			if err != nil {
+				if conf.Optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				conf.unauthorized(w, r, "", err)
+				return
+			}
+
+			principal, err := conf.Authenticator.Authenticate(r.Context(), token)
+			if err != nil {
+				conf.unauthorized(w, r, "invalid_token", err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func (conf AuthConfig) unauthorized(w http.ResponseWriter, r *http.Request, code string, err error) {
+	challenge := "Bearer"
+	if conf.Realm != "" {
+		challenge += fmt.Sprintf(` realm=%q`, conf.Realm)
+	}
+	if code != "" {
+		if conf.Realm != "" {
+			challenge += ","
+		}
+		challenge += fmt.Sprintf(` error=%q`, code)
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	RequestErrorResponseJSON(w, r, http.StatusUnauthorized, 0, err)
+}
+
+// HMACAuthenticator issues and validates tokens signed with HMAC-SHA256 shared key
+type HMACAuthenticator struct {
+	Key []byte
+	TTL time.Duration
+	// Now returns current time, time.Now is used if nil
+	Now func() time.Time
+}
+
+// NewHMACAuthenticator returns authenticator issuing tokens valid for ttl, key must not be empty
+func NewHMACAuthenticator(key []byte, ttl time.Duration) (*HMACAuthenticator, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptySigningKey
+	}
+	return &HMACAuthenticator{Key: key, TTL: ttl}, nil
+}
+
+// HMACTokenClaims are stored in Principal.Claims by HMACAuthenticator
+type HMACTokenClaims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+func (a *HMACAuthenticator) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// Issue returns signed token for the subject, valid for TTL, or forever if TTL is zero
+func (a *HMACAuthenticator) Issue(subject string) (string, error) {
+	if subject == "" {
+		return "", ErrEmptySubject
+	}
+
+	now := a.now()
+	claims := HMACTokenClaims{
+		Subject:  subject,
+		IssuedAt: now.Unix(),
+	}
+	if a.TTL > 0 {
+		claims.ExpiresAt = now.Add(a.TTL).Unix()
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	return signPayload(a.Key, signPurposeAuth, payload)
+}
+
+// Authenticate conforms to the Authenticator interface
+func (a *HMACAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	payload, err := verifyPayload(a.Key, signPurposeAuth, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims HMACTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return nil, ErrInvalidSignature
+	}
+
+	if claims.ExpiresAt > 0 && a.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return &Principal{Subject: claims.Subject, Claims: claims}, nil
+}
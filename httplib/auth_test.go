@@ -0,0 +1,144 @@
+package httplib
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	now := time.Date(2022, 5, 19, 12, 0, 0, 0, time.UTC)
+	a := &HMACAuthenticator{
+		Key: []byte("secret"),
+		TTL: time.Hour,
+		Now: func() time.Time { return now },
+	}
+
+	token, err := a.Issue("user-1")
+	if err != nil {
+		t.Fatalf("Issue() error = %s", err)
+	}
+
+	handler := AuthMiddleware(AuthConfig{
+		Authenticator: a,
+		Realm:         "api",
+		TokenExtractor: TokenExtractor{
+			Sources:    []TokenSource{TokenFromHeader, TokenFromQuery},
+			QueryParam: "access_token",
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok || p.Subject != "user-1" {
+			t.Errorf("principal = %+v, want subject user-1", p)
+		}
+	}))
+
+	tests := []struct {
+		name      string
+		target    string
+		header    string
+		cookie    string
+		elapsed   time.Duration
+		code      int
+		challenge string
+	}{
+		{name: "bearer header", target: "/", header: PrefixBearer + token, code: http.StatusOK},
+		{name: "query parameter", target: "/?access_token=" + token, code: http.StatusOK},
+		{name: "cookie is not a configured source", target: "/", cookie: token, code: http.StatusUnauthorized, challenge: `Bearer realm="api"`},
+		{name: "tampered", target: "/", header: PrefixBearer + token + "x", code: http.StatusUnauthorized, challenge: `Bearer realm="api", error="invalid_token"`},
+		{name: "expired", target: "/", header: PrefixBearer + token, elapsed: time.Hour, code: http.StatusUnauthorized, challenge: `Bearer realm="api", error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = time.Date(2022, 5, 19, 12, 0, 0, 0, time.UTC).Add(tt.elapsed)
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: CookieName, Value: tt.cookie})
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d", w.Code, tt.code)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("WWW-Authenticate = %s, want %s", got, tt.challenge)
+			}
+			if tt.code != http.StatusOK && !strings.Contains(w.Body.String(), `"status":401`) {
+				t.Errorf("body = %s, want ApiError", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHMACAuthenticator_Authenticate(t *testing.T) {
+	key := []byte("shared secret")
+	if _, err := NewHMACAuthenticator(nil, time.Hour); err != ErrEmptySigningKey {
+		t.Errorf("NewHMACAuthenticator(nil) error = %v, want %v", err, ErrEmptySigningKey)
+	}
+
+	a, err := NewHMACAuthenticator(key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Issue(""); err != ErrEmptySubject {
+		t.Errorf("Issue(\"\") error = %v, want %v", err, ErrEmptySubject)
+	}
+
+	// cursor signed with the same key must not be accepted as a token
+	codec, _ := NewCursorCodec(key)
+	cursor, err := codec.Encode(Cursor{Values: []interface{}{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Authenticate(context.Background(), cursor); err != ErrInvalidSignature {
+		t.Errorf("Authenticate(cursor) error = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	// token without subject signed for authentication is rejected as well
+	noSubject, err := signPayload(key, signPurposeAuth, []byte(`{"iat":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Authenticate(context.Background(), noSubject); err != ErrInvalidSignature {
+		t.Errorf("Authenticate() of token without subject error = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	if _, err := (&HMACAuthenticator{}).Authenticate(context.Background(), noSubject); err != ErrEmptySigningKey {
+		t.Errorf("Authenticate() with empty key error = %v, want %v", err, ErrEmptySigningKey)
+	}
+}
+
+func TestExtractTokenFromRequest_NoCookie(t *testing.T) {
+	if _, err := ExtractTokenFromRequest(httptest.NewRequest(http.MethodGet, "/", nil)); err != ErrNoToken {
+		t.Errorf("ExtractTokenFromRequest() error = %v, want %v", err, ErrNoToken)
+	}
+}
+
+func TestTokenExtractor_queryIgnoresBody(t *testing.T) {
+	e := TokenExtractor{Sources: []TokenSource{TokenFromQuery}, QueryParam: "access_token"}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("access_token=from-body"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token, err := e.Extract(r); err != ErrNoToken {
+		t.Errorf("Extract() = %q, %v, want %v", token, err, ErrNoToken)
+	}
+	if body, _ := ioutil.ReadAll(r.Body); string(body) != "access_token=from-body" {
+		t.Errorf("request body is consumed, left %q", body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/?access_token=from-query", nil)
+	if token, err := e.Extract(r); err != nil || token != "from-query" {
+		t.Errorf("Extract() = %q, %v, want from-query", token, err)
+	}
+}
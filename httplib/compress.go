@@ -0,0 +1,395 @@
+package httplib
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rovergulf/utils/response"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+
+	headerAcceptEncoding  = "Accept-Encoding"
+	headerContentEncoding = "Content-Encoding"
+	headerContentLength   = "Content-Length"
+	headerContentType     = "Content-Type"
+)
+
+// CompressionConfig configures CompressionMiddleware
+type CompressionConfig struct {
+	// Encodings are supported content encodings, in order of preference
+	// among encodings equally acceptable by client
+	Encodings []string
+	// MinSize is a minimal response size in bytes to be compressed
+	MinSize int
+	// ContentTypes is an allowlist of compressed media types, entries may be
+	// exact media types ("application/json"), type wildcards ("text/*")
+	// or structured syntax suffixes ("+json")
+	ContentTypes []string
+}
+
+// DefaultCompressionConfig returns config compressing text, JSON, YAML, XML and CSV
+// responses larger than 1KB with zstd, gzip or deflate
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		Encodings: []string{EncodingZstd, EncodingGzip, EncodingDeflate},
+		MinSize:   1024,
+		ContentTypes: []string{
+			"text/*",
+			"application/json",
+			"application/x-ndjson",
+			"application/yaml",
+			"application/xml",
+			"application/javascript",
+			"application/msgpack",
+			"+json",
+			"+xml",
+			"image/svg+xml",
+		},
+	}
+}
+
+// isContentTypeAllowed reports whether Content-Type header value matches allowlist,
+// text/event-stream is never compressed since it has to be delivered immediately
+func (c CompressionConfig) isContentTypeAllowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "text/event-stream" {
+		return false
+	}
+
+	for _, allowed := range c.ContentTypes {
+		switch {
+		case strings.HasPrefix(allowed, "+"):
+			if strings.HasSuffix(mediaType, allowed) {
+				return true
+			}
+		case strings.HasSuffix(allowed, "/*"):
+			if strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+		case strings.EqualFold(allowed, mediaType):
+			return true
+		}
+	}
+
+	return false
+}
+
+// negotiateEncoding returns the most acceptable supported encoding, or empty string if there is none
+func (c CompressionConfig) negotiateEncoding(acceptEncoding string) string {
+	values := response.ParseQualityValues(acceptEncoding)
+
+	var best string
+	var bestQ float64
+	for _, enc := range c.Encodings {
+		q, exact := 0.0, false
+		for _, v := range values {
+			if v.Value == enc {
+				q, exact = v.Q, true
+			} else if v.Value == "*" && !exact {
+				q = v.Q
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	return best
+}
+
+// CompressionMiddleware compresses responses with encoding negotiated by Accept-Encoding header.
+// Responses smaller than MinSize, of media types not in allowlist, already encoded,
+// partial or flushed before MinSize is reached, such as streams, are passed through as is.
+func CompressionMiddleware(conf CompressionConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add(headerVary, headerAcceptEncoding)
+
+			encoding := conf.negotiateEncoding(r.Header.Get(headerAcceptEncoding))
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, conf: conf, encoding: encoding}
+			defer cw.Close()
+
+			next.ServeHTTP(cw.expose(), r)
+		})
+	}
+}
+
+// compressWriter buffers response until MinSize is reached to decide whether it is compressed
+type compressWriter struct {
+	http.ResponseWriter
+	conf     CompressionConfig
+	encoding string
+
+	status    int
+	buf       []byte
+	decided   bool
+	streaming bool
+	enc       io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+
+	// informational responses are sent immediately
+	if code >= 100 && code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.status = code
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < w.conf.MinSize {
+		if cl, err := strconv.Atoi(w.Header().Get(headerContentLength)); err != nil || cl >= w.conf.MinSize {
+			return len(p), nil
+		}
+	}
+
+	if err := w.decide(); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// decide starts compression if response is eligible and writes buffered data
+func (w *compressWriter) decide() error {
+	w.decided = true
+
+	h := w.Header()
+	if h.Get(headerContentType) == "" && len(w.buf) > 0 {
+		h.Set(headerContentType, http.DetectContentType(w.buf))
+	}
+
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if w.isEligible(status) {
+		enc, err := newCompressor(w.encoding, w.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		w.enc = enc
+		h.Set(headerContentEncoding, w.encoding)
+		h.Del(headerContentLength)
+
+		// compressed representation is not byte-for-byte identical to the uncompressed one
+		if etag := h.Get(headerETag); etag != "" {
+			h.Set(headerETag, encodedETag(etag, w.encoding))
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) isEligible(status int) bool {
+	if w.streaming || status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+
+	h := w.Header()
+	if h.Get(headerContentEncoding) != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+
+	return len(w.buf) >= w.conf.MinSize && w.conf.isContentTypeAllowed(h.Get(headerContentType))
+}
+
+// expose returns w extended with optional interfaces of the wrapped writer, as wrapResponseWriter does
+func (w *compressWriter) expose() http.ResponseWriter {
+	_, canFlush := w.ResponseWriter.(http.Flusher)
+	_, canHijack := w.ResponseWriter.(http.Hijacker)
+	_, canPush := w.ResponseWriter.(http.Pusher)
+	f, h, p := cwFlusher{w}, cwHijacker{w}, cwPusher{w}
+
+	switch {
+	case canFlush && canHijack && canPush:
+		return struct {
+			*compressWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, f, h, p}
+	case canFlush && canHijack:
+		return struct {
+			*compressWriter
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case canFlush && canPush:
+		return struct {
+			*compressWriter
+			http.Flusher
+			http.Pusher
+		}{w, f, p}
+	case canHijack && canPush:
+		return struct {
+			*compressWriter
+			http.Hijacker
+			http.Pusher
+		}{w, h, p}
+	case canFlush:
+		return struct {
+			*compressWriter
+			http.Flusher
+		}{w, f}
+	case canHijack:
+		return struct {
+			*compressWriter
+			http.Hijacker
+		}{w, h}
+	case canPush:
+		return struct {
+			*compressWriter
+			http.Pusher
+		}{w, p}
+	default:
+		return w
+	}
+}
+
+type cwFlusher struct{ w *compressWriter }
+
+// Flush sends buffered data, response flushed before compression is decided is passed through
+func (f cwFlusher) Flush() {
+	f.w.flush()
+}
+
+func (w *compressWriter) flush() {
+	if !w.decided {
+		w.streaming = true
+		w.decide()
+	}
+
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// Close writes pending data and finishes compressed stream
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// nothing is written by handler, leave default response as is
+			return nil
+		}
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	releaseCompressor(w.encoding, w.enc)
+	w.enc = nil
+	return err
+}
+
+type cwHijacker struct{ w *compressWriter }
+
+func (h cwHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type cwPusher struct{ w *compressWriter }
+
+func (p cwPusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+var compressorPools = map[string]*sync.Pool{
+	EncodingGzip:    {},
+	EncodingDeflate: {},
+	EncodingZstd:    {},
+}
+
+func newCompressor(encoding string, w io.Writer) (io.WriteCloser, error) {
+	if pool, ok := compressorPools[encoding]; ok {
+		if enc, ok := pool.Get().(compressor); ok {
+			enc.Reset(w)
+			return enc, nil
+		}
+	}
+
+	switch encoding {
+	case EncodingGzip:
+		return gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	case EncodingDeflate:
+		// HTTP deflate coding is zlib wrapped DEFLATE stream, RFC 9110 section 8.4.1.2
+		return zlib.NewWriterLevel(w, flate.DefaultCompression)
+	case EncodingZstd:
+		// single goroutine encoder is faster for typical response sizes
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedDefault))
+	default:
+		return nil, fmt.Errorf("httplib: unsupported content encoding %q", encoding)
+	}
+}
+
+type compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+func releaseCompressor(encoding string, enc io.WriteCloser) {
+	if pool, ok := compressorPools[encoding]; ok {
+		if c, ok := enc.(compressor); ok {
+			c.Reset(nil)
+			pool.Put(c)
+		}
+	}
+}
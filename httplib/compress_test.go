@@ -0,0 +1,248 @@
+package httplib
+
+import (
+	"bytes"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressionConfig_negotiateEncoding(t *testing.T) {
+	conf := DefaultCompressionConfig()
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", EncodingGzip},
+		{"gzip, deflate, br, zstd", EncodingZstd},
+		{"gzip;q=1, zstd;q=0.5", EncodingGzip},
+		{"*;q=0.5, gzip;q=0.8", EncodingGzip},
+		{"*", EncodingZstd},
+		{"*, zstd;q=0", EncodingGzip},
+		{"identity, br", ""},
+	}
+
+	for _, tt := range tests {
+		if got := conf.negotiateEncoding(tt.accept); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"name":"compressible"},`, 100)
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		encoding    string
+		body        string
+		flush       bool
+		want        string
+	}{
+		{"gzip", "gzip", "application/json", "", large, false, EncodingGzip},
+		{"zstd", "zstd, gzip", "application/problem+json", "", large, false, EncodingZstd},
+		{"deflate", "deflate", "text/csv", "", large, false, EncodingDeflate},
+		{"small", "gzip", "application/json", "", "{}", false, ""},
+		{"not allowed type", "gzip", "image/png", "", large, false, ""},
+		{"already encoded", "gzip", "application/json", "br", large, false, "br"},
+		{"not accepted", "br", "application/json", "", large, false, ""},
+		{"streaming", "gzip", "application/x-ndjson", "", large, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CompressionMiddleware(DefaultCompressionConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				if tt.flush {
+					w.Write([]byte("{}\n"))
+					w.(http.Flusher).Flush()
+				}
+				w.Write([]byte(tt.body[:len(tt.body)/2]))
+				w.Write([]byte(tt.body[len(tt.body)/2:]))
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", tt.accept)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.want {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+
+			var body []byte
+			switch tt.want {
+			case EncodingGzip:
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, _ = io.ReadAll(zr)
+			case EncodingDeflate:
+				zr, err := zlib.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, _ = io.ReadAll(zr)
+			case EncodingZstd:
+				zr, err := zstd.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer zr.Close()
+				body, _ = io.ReadAll(zr)
+			default:
+				body = w.Body.Bytes()
+			}
+
+			want := tt.body
+			if tt.flush {
+				want = "{}\n" + want
+			}
+			if !bytes.Equal(body, []byte(want)) {
+				t.Errorf("body = %q, want %q", body, want)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_ETag(t *testing.T) {
+	large := strings.Repeat("compressible ", 200)
+
+	for _, tt := range []struct {
+		accept string
+		etag   string
+		want   string
+	}{
+		{"gzip", `"v1"`, `"v1-gzip"`},
+		{"zstd", `"v1"`, `"v1-zstd"`},
+		{"gzip", `W/"v1"`, `W/"v1"`},
+		{"", `"v1"`, `"v1"`},
+	} {
+		handler := CompressionMiddleware(DefaultCompressionConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("ETag", tt.etag)
+			w.Write([]byte(large))
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", tt.accept)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got := w.Header().Get("ETag"); got != tt.want {
+			t.Errorf("Accept-Encoding %q, ETag %s: got %s, want %s", tt.accept, tt.etag, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionMiddleware_ifMatch(t *testing.T) {
+	resource := map[string]string{"description": strings.Repeat("compressible ", 200)}
+
+	handler := CompressionMiddleware(DefaultCompressionConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			ResponseJSONWithETag(w, r, resource)
+			return
+		}
+
+		etag, err := ETagOf(resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if CheckPreconditions(w, r, etag, time.Time{}) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	etag := w.Header().Get("ETag")
+	if w.Header().Get("Content-Encoding") != "gzip" || !strings.HasSuffix(etag, `-gzip"`) {
+		t.Fatalf("expected gzip response with coding specific ETag, got %q %s", w.Header().Get("Content-Encoding"), etag)
+	}
+
+	for _, tt := range []struct {
+		ifMatch string
+		want    int
+	}{
+		{etag, http.StatusNoContent},
+		{`"stale-gzip"`, http.StatusPreconditionFailed},
+		{"W/" + etag, http.StatusPreconditionFailed},
+	} {
+		r = httptest.NewRequest(http.MethodPut, "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		r.Header.Set("If-Match", tt.ifMatch)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.want {
+			t.Errorf("If-Match %s: status = %d, want %d", tt.ifMatch, w.Code, tt.want)
+		}
+	}
+
+	// client revalidates its compressed copy
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match %s: status = %d, want %d", etag, w.Code, http.StatusNotModified)
+	}
+}
+
+func TestCompressionMiddleware_interfaces(t *testing.T) {
+	tests := []struct {
+		name                      string
+		w                         http.ResponseWriter
+		flusher, hijacker, pusher bool
+	}{
+		{"plain", plainWriter{httptest.NewRecorder()}, false, false, false},
+		{"flusher", httptest.NewRecorder(), true, false, false},
+		{"hijacker", hijackWriter{httptest.NewRecorder()}, false, true, false},
+		{"flusher and pusher", &pushWriter{ResponseRecorder: httptest.NewRecorder()}, true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CompressionMiddleware(DefaultCompressionConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := w.(http.Flusher); ok != tt.flusher {
+					t.Errorf("http.Flusher = %v, want %v", ok, tt.flusher)
+				}
+				if _, ok := w.(http.Hijacker); ok != tt.hijacker {
+					t.Errorf("http.Hijacker = %v, want %v", ok, tt.hijacker)
+				}
+				if _, ok := w.(http.Pusher); ok != tt.pusher {
+					t.Errorf("http.Pusher = %v, want %v", ok, tt.pusher)
+				}
+				if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); !ok || u.Unwrap() != tt.w {
+					t.Errorf("Unwrap() does not return wrapped writer")
+				}
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+			handler.ServeHTTP(tt.w, r)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package httplib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// HeaderRequestID is a header used to pass and echo request ID
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength limits size of the request ID accepted from client
+const maxRequestIDLength = 128
+
+type contextKey int
+
+const (
+	requestInfoKey contextKey = iota
+	principalKey
+	csrfTokenKey
+)
+
+// RequestInfo contains request metadata stored in context by Interceptor
+type RequestInfo struct {
+	ID             string
+	Host           string
+	Path           string
+	RemoteAddr     string
+	XForwardedFor  string
+	CFConnectingIP string
+	// Route is a path template of the matched mux route
+	Route string
+}
+
+// ContextWithRequestInfo returns a copy of ctx holding specified request info
+func ContextWithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+// RequestInfoFromContext returns request info stored by Interceptor, if any
+func RequestInfoFromContext(ctx context.Context) (*RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey).(*RequestInfo)
+	return info, ok
+}
+
+// RequestIDFromContext returns ID of the request, empty string if it's not set
+func RequestIDFromContext(ctx context.Context) string {
+	if info, ok := RequestInfoFromContext(ctx); ok {
+		return info.ID
+	}
+	return ""
+}
+
+// requestID returns valid request ID passed by client or generates a new one
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(HeaderRequestID); isValidRequestID(id) {
+		return id
+	}
+
+	return newRequestID()
+}
+
+func isValidRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		// visible ASCII only, to keep it safe for headers and logs
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,80 @@
+package httplib
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInterceptor_ContextMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	i := &Interceptor{Router: router}
+
+	tests := []struct {
+		name   string
+		header string
+		echo   bool
+	}{
+		{"valid", "req-42", true},
+		{"max length", strings.Repeat("a", maxRequestIDLength), true},
+		{"missing", "", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"space", "req 42", false},
+		{"control character", "req\x0142", false},
+		{"non ascii", "запрос", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info *RequestInfo
+			handler := i.ContextMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				info, _ = RequestInfoFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			if tt.header != "" {
+				r.Header.Set(HeaderRequestID, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if info == nil {
+				t.Fatal("request info is not stored in context")
+			}
+			if got := w.Header().Get(HeaderRequestID); got != info.ID {
+				t.Errorf("response %s = %q, want %q", HeaderRequestID, got, info.ID)
+			}
+			if tt.echo && info.ID != tt.header {
+				t.Errorf("request ID = %q, want echoed %q", info.ID, tt.header)
+			}
+			if !tt.echo && (info.ID == tt.header || len(info.ID) != 32) {
+				t.Errorf("request ID = %q, want generated one", info.ID)
+			}
+			if info.Route != "/users/{id}" || info.Path != "/users/1" || info.RemoteAddr != "192.0.2.1:1234" {
+				t.Errorf("request info = %+v", info)
+			}
+		})
+	}
+}
+
+func TestRequestInfoFromContext(t *testing.T) {
+	if info, ok := RequestInfoFromContext(context.Background()); ok || info != nil {
+		t.Errorf("RequestInfoFromContext() = %+v, %v for empty context", info, ok)
+	}
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("RequestIDFromContext() = %q for empty context", id)
+	}
+
+	ctx := ContextWithRequestInfo(context.Background(), &RequestInfo{ID: "req-1"})
+	if info, ok := RequestInfoFromContext(ctx); !ok || info.ID != "req-1" {
+		t.Errorf("RequestInfoFromContext() = %+v, %v", info, ok)
+	}
+	if id := RequestIDFromContext(ctx); id != "req-1" {
+		t.Errorf("RequestIDFromContext() = %q, want req-1", id)
+	}
+}
@@ -1,8 +1,189 @@
 package httplib
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/rovergulf/utils/encrypt"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// CookieName is a default name of the session cookie,
+// SessionCookies should be preferred to avoid package level state
 var CookieName = "session"
 
+var ErrSessionExpired = fmt.Errorf("httplib: session cookie is expired")
+
 // SetCookieName sets value of the CookieName package variable
 func SetCookieName(name string) {
 	CookieName = name
 }
+
+// SessionCookies sets, reads, refreshes and clears session cookie
+type SessionCookies struct {
+	Name     string
+	Domain   string
+	Path     string
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+	// MaxAge is a cookie lifetime, zero value makes it a browser session cookie
+	MaxAge time.Duration
+	// Key enables AES-GCM encryption of the cookie value, it must be 16, 24 or 32 bytes long.
+	// Encrypted value is sealed with its expiry time, so it is rejected after MaxAge
+	// even if client keeps the cookie.
+	Key    []byte
+	Logger *zap.SugaredLogger
+
+	now func() time.Time
+}
+
+// sessionPayload is encrypted value of the session cookie
+type sessionPayload struct {
+	Value string `json:"v"`
+	// ExpiresAt is unix time the value is valid until, zero if MaxAge is not set
+	ExpiresAt int64 `json:"exp,omitempty"`
+}
+
+// NewSessionCookies returns SessionCookies with secure defaults
+func NewSessionCookies(name string, maxAge time.Duration) *SessionCookies {
+	return &SessionCookies{
+		Name:     name,
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	}
+}
+
+func (s *SessionCookies) cookie(value string, maxAge time.Duration) *http.Cookie {
+	c := &http.Cookie{
+		Name:     s.Name,
+		Value:    value,
+		Domain:   s.Domain,
+		Path:     s.Path,
+		Secure:   s.Secure,
+		HttpOnly: s.HttpOnly,
+		SameSite: s.SameSite,
+	}
+
+	if maxAge > 0 {
+		c.MaxAge = int(maxAge / time.Second)
+		c.Expires = s.currentTime().Add(maxAge)
+	} else if maxAge < 0 {
+		c.MaxAge = -1
+		c.Expires = time.Unix(1, 0)
+	}
+
+	return c
+}
+
+func (s *SessionCookies) currentTime() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *SessionCookies) logger() *zap.SugaredLogger {
+	if s.Logger == nil {
+		return zap.NewNop().Sugar()
+	}
+	return s.Logger
+}
+
+// Set writes session cookie with specified value, encrypted along with its expiry time if Key is set
+func (s *SessionCookies) Set(w http.ResponseWriter, value string) error {
+	if len(s.Key) > 0 {
+		payload := sessionPayload{Value: value}
+		if s.MaxAge > 0 {
+			payload.ExpiresAt = s.currentTime().Add(s.MaxAge).Unix()
+		}
+
+		plaintext, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		ciphertext, err := encrypt.Encrypt(s.logger(), plaintext, s.Key)
+		if err != nil {
+			return err
+		}
+		value = base64.RawURLEncoding.EncodeToString(ciphertext)
+	}
+
+	http.SetCookie(w, s.cookie(value, s.MaxAge))
+	return nil
+}
+
+// Get returns decrypted session cookie value, http.ErrNoCookie if cookie is absent,
+// or ErrSessionExpired if encrypted value is expired
+func (s *SessionCookies) Get(r *http.Request) (string, error) {
+	c, err := r.Cookie(s.Name)
+	if err != nil {
+		return "", err
+	}
+
+	if len(s.Key) == 0 {
+		return c.Value, nil
+	}
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return "", fmt.Errorf("httplib: malformed session cookie: %s", err)
+	}
+
+	plaintext, err := encrypt.Decrypt(s.logger(), ciphertext, s.Key)
+	if err != nil {
+		return "", fmt.Errorf("httplib: unable to decrypt session cookie: %s", err)
+	}
+
+	var payload sessionPayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return "", fmt.Errorf("httplib: malformed session cookie: %s", err)
+	}
+
+	if payload.ExpiresAt > 0 && s.currentTime().Unix() >= payload.ExpiresAt {
+		return "", ErrSessionExpired
+	}
+
+	return payload.Value, nil
+}
+
+// Refresh extends lifetime of valid session cookie for another MaxAge, if request has one.
+// Encrypted value is sealed again with the new expiry time.
+func (s *SessionCookies) Refresh(w http.ResponseWriter, r *http.Request) error {
+	if s.MaxAge <= 0 {
+		return nil
+	}
+
+	value, err := s.Get(r)
+	if err != nil {
+		return err
+	}
+
+	return s.Set(w, value)
+}
+
+// Clear removes session cookie from the client, e.g. on logout
+func (s *SessionCookies) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, s.cookie("", -1))
+}
+
+// Logout clears session cookie and responds with 204 status
+func (s *SessionCookies) Logout(w http.ResponseWriter, r *http.Request) {
+	s.Clear(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SlidingExpiry is a middleware refreshing valid session cookie on every request
+func (s *SessionCookies) SlidingExpiry(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Refresh(w, r)
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,139 @@
+package httplib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionCookies(t *testing.T) {
+	s := NewSessionCookies("sid", time.Hour)
+	s.Key = []byte("0123456789abcdef0123456789abcdef")
+
+	w := httptest.NewRecorder()
+	if err := s.Set(w, "session-token"); err != nil {
+		t.Fatalf("Set() error = %s", err)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies = %d, want 1", len(cookies))
+	}
+	c := cookies[0]
+	if c.Value == "session-token" || !c.Secure || !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.MaxAge != 3600 {
+		t.Errorf("cookie = %+v, want encrypted secure cookie", c)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(c)
+	if v, err := s.Get(r); err != nil || v != "session-token" {
+		t.Errorf("Get() = %q, %v, want %q", v, err, "session-token")
+	}
+
+	other := *s
+	other.Key = []byte("fedcba9876543210fedcba9876543210")
+	if _, err := other.Get(r); err == nil {
+		t.Errorf("Get() with other key succeeded")
+	}
+
+	w = httptest.NewRecorder()
+	s.Clear(w)
+	if c := w.Result().Cookies()[0]; c.MaxAge >= 0 || c.Value != "" {
+		t.Errorf("cleared cookie = %+v, want expired", c)
+	}
+}
+
+func TestSessionCookies_expiry(t *testing.T) {
+	now := time.Date(2022, 5, 19, 12, 0, 0, 0, time.UTC)
+	s := NewSessionCookies("sid", time.Hour)
+	s.Key = []byte("0123456789abcdef")
+	s.now = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	if err := s.Set(w, "session-token"); err != nil {
+		t.Fatal(err)
+	}
+	captured := w.Result().Cookies()[0]
+
+	request := func(c *http.Cookie) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(c)
+		return r
+	}
+
+	// client controls cookie attributes, so captured value must expire by itself
+	now = now.Add(time.Hour)
+	if _, err := s.Get(request(captured)); err != ErrSessionExpired {
+		t.Errorf("Get() of expired cookie error = %v, want %v", err, ErrSessionExpired)
+	}
+	if err := s.Refresh(httptest.NewRecorder(), request(captured)); err != ErrSessionExpired {
+		t.Errorf("Refresh() of expired cookie error = %v, want %v", err, ErrSessionExpired)
+	}
+
+	// refresh seals value with a new expiry time
+	now = now.Add(-30 * time.Minute)
+	w = httptest.NewRecorder()
+	if err := s.Refresh(w, request(captured)); err != nil {
+		t.Fatal(err)
+	}
+	refreshed := w.Result().Cookies()[0]
+	if refreshed.Value == captured.Value || refreshed.MaxAge != 3600 {
+		t.Errorf("refreshed cookie = %+v", refreshed)
+	}
+
+	now = now.Add(45 * time.Minute)
+	if v, err := s.Get(request(refreshed)); err != nil || v != "session-token" {
+		t.Errorf("Get() of refreshed cookie = %q, %v", v, err)
+	}
+}
+
+func TestSessionCookies_SlidingExpiry(t *testing.T) {
+	s := NewSessionCookies("sid", time.Hour)
+	s.Key = []byte("0123456789abcdef")
+
+	w := httptest.NewRecorder()
+	s.Set(w, "session-token")
+	valid := w.Result().Cookies()[0]
+
+	handler := s.SlidingExpiry(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name    string
+		cookie  *http.Cookie
+		refresh bool
+	}{
+		{"valid", valid, true},
+		{"missing", nil, false},
+		{"tampered", &http.Cookie{Name: "sid", Value: valid.Value[:len(valid.Value)-2] + "AA"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != nil {
+				r.AddCookie(tt.cookie)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if refreshed := len(w.Result().Cookies()) == 1; refreshed != tt.refresh {
+				t.Errorf("cookie refreshed = %v, want %v", refreshed, tt.refresh)
+			}
+		})
+	}
+}
+
+func TestSessionCookies_Logout(t *testing.T) {
+	s := NewSessionCookies("sid", time.Hour)
+	w := httptest.NewRecorder()
+	s.Logout(w, httptest.NewRequest(http.MethodPost, "/logout", nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "sid" || cookies[0].MaxAge >= 0 || cookies[0].Value != "" {
+		t.Errorf("cookies = %+v, want expired session cookie", cookies)
+	}
+}
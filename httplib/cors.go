@@ -0,0 +1,213 @@
+package httplib
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerOrigin                      = "Origin"
+	headerVary                        = "Vary"
+	headerAllowOrigin                 = "Access-Control-Allow-Origin"
+	headerAllowCredentials            = "Access-Control-Allow-Credentials"
+	headerAllowMethods                = "Access-Control-Allow-Methods"
+	headerAllowHeaders                = "Access-Control-Allow-Headers"
+	headerExposeHeaders               = "Access-Control-Expose-Headers"
+	headerMaxAge                      = "Access-Control-Max-Age"
+	headerAccessControlRequestMethod  = "Access-Control-Request-Method"
+	headerAccessControlRequestHeaders = "Access-Control-Request-Headers"
+	corsWildcard                      = "*"
+)
+
+// CORSPolicy describes which cross-origin requests are allowed
+//
+// AllowedOrigins entries may be an exact origin ("https://app.example.com"),
+// a subdomain wildcard ("https://*.example.com" or "*.example.com")
+// or "*" to allow any origin. Origins allowed by "*" only are answered with wildcard
+// and never get credentials, even if AllowCredentials is set.
+type CORSPolicy struct {
+	AllowedOrigins        []string
+	AllowedOriginPatterns []*regexp.Regexp
+	AllowedMethods        []string
+	AllowedHeaders        []string
+	ExposedHeaders        []string
+	AllowCredentials      bool
+	MaxAge                time.Duration
+}
+
+// DefaultCORSPolicy returns policy with common methods and headers allowed,
+// but without any allowed origin, so cross-origin requests are rejected
+// until origins are configured explicitly
+func DefaultCORSPolicy() *CORSPolicy {
+	return &CORSPolicy{
+		AllowedMethods:   append([]string(nil), allowedMethods...),
+		AllowedHeaders:   append([]string(nil), allowedHeaders...),
+		ExposedHeaders:   []string{HeaderRequestID},
+		AllowCredentials: true,
+	}
+}
+
+// IsOriginAllowed reports whether specified origin matches the policy
+func (p *CORSPolicy) IsOriginAllowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == corsWildcard {
+			return true
+		}
+	}
+
+	return p.isOriginListed(origin)
+}
+
+// isOriginListed reports whether origin matches any of allowed origins or patterns, except for "*"
+func (p *CORSPolicy) isOriginListed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		if allowed != corsWildcard && matchOrigin(strings.ToLower(allowed), origin) {
+			return true
+		}
+	}
+
+	for _, re := range p.AllowedOriginPatterns {
+		if re != nil && re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchOrigin(allowed, origin string) bool {
+	if allowed == origin {
+		return true
+	}
+
+	idx := strings.Index(allowed, "*.")
+	if idx < 0 {
+		return false
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	// "https://*.example.com" requires scheme to match, "*.example.com" accepts any
+	if scheme := strings.TrimSuffix(allowed[:idx], "://"); scheme != "" && scheme != u.Scheme {
+		return false
+	}
+
+	return strings.HasSuffix(u.Host, allowed[idx+1:])
+}
+
+func (p *CORSPolicy) isMethodAllowed(method string) bool {
+	// simple methods are always allowed by browsers
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodPost {
+		return true
+	}
+
+	for _, m := range p.AllowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *CORSPolicy) areHeadersAllowed(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+
+		var found bool
+		for _, allowed := range p.AllowedHeaders {
+			if allowed == corsWildcard || strings.EqualFold(allowed, h) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *CORSPolicy) setAllowOrigin(w http.ResponseWriter, origin string) {
+	h := w.Header()
+	h.Add(headerVary, headerOrigin)
+
+	if !p.isOriginListed(origin) {
+		// origin is allowed by wildcard only, reflecting it along with credentials
+		// would let any site make authenticated requests
+		h.Set(headerAllowOrigin, corsWildcard)
+		return
+	}
+
+	h.Set(headerAllowOrigin, origin)
+	if p.AllowCredentials {
+		h.Set(headerAllowCredentials, "true")
+	}
+}
+
+// handleRequest sets CORS headers for actual (non-preflight) cross-origin request
+func (p *CORSPolicy) handleRequest(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get(headerOrigin)
+	if !p.IsOriginAllowed(origin) {
+		w.Header().Add(headerVary, headerOrigin)
+		return
+	}
+
+	p.setAllowOrigin(w, origin)
+	if len(p.ExposedHeaders) > 0 {
+		w.Header().Set(headerExposeHeaders, strings.Join(p.ExposedHeaders, headersSep))
+	}
+}
+
+// handlePreflight responds to preflight request,
+// rejecting it with 403 if origin, method or any of requested headers are not allowed
+func (p *CORSPolicy) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Add(headerVary, headerAccessControlRequestMethod)
+	h.Add(headerVary, headerAccessControlRequestHeaders)
+
+	origin := r.Header.Get(headerOrigin)
+	if !p.IsOriginAllowed(origin) ||
+		!p.isMethodAllowed(r.Header.Get(headerAccessControlRequestMethod)) ||
+		!p.areHeadersAllowed(r.Header.Get(headerAccessControlRequestHeaders)) {
+		h.Add(headerVary, headerOrigin)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	p.setAllowOrigin(w, origin)
+	if len(p.AllowedMethods) > 0 {
+		h.Set(headerAllowMethods, strings.Join(p.AllowedMethods, headersSep))
+	}
+	if len(p.AllowedHeaders) > 0 {
+		h.Set(headerAllowHeaders, strings.Join(p.AllowedHeaders, headersSep))
+	}
+	if p.MaxAge > 0 {
+		h.Set(headerMaxAge, strconv.Itoa(int(p.MaxAge/time.Second)))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func isPreflightRequest(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get(headerOrigin) != "" &&
+		r.Header.Get(headerAccessControlRequestMethod) != ""
+}
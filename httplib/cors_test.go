@@ -0,0 +1,110 @@
+package httplib
+
+import (
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCORSPolicy_IsOriginAllowed(t *testing.T) {
+	p := &CORSPolicy{
+		AllowedOrigins:        []string{"https://app.example.com", "https://*.rovergulf.net", "*.dev.local"},
+		AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^https://pr-\d+\.preview\.io$`)},
+	}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://api.rovergulf.net", true},
+		{"http://api.rovergulf.net", false},
+		{"https://rovergulf.net", false},
+		{"https://evilrovergulf.net", false},
+		{"http://web.dev.local", true},
+		{"https://pr-42.preview.io", true},
+		{"https://pr-x.preview.io", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := p.IsOriginAllowed(tt.origin); got != tt.want {
+			t.Errorf("IsOriginAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestInterceptor_CORS(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/public", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodPut)
+	router.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodPut)
+	router.HandleFunc("/any", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodPut)
+	router.HandleFunc("/mixed", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodPut)
+
+	i := NewInterceptor(zap.NewNop().Sugar(), nil, nil)
+	i.Router = router
+	i.CORS.AllowedOrigins = []string{"https://app.example.com"}
+	i.CORS.MaxAge = 10 * time.Minute
+	// default policy allows credentials
+	anyOrigin := DefaultCORSPolicy()
+	anyOrigin.AllowedOrigins = []string{"*"}
+	i.RouteCORS = map[string]*CORSPolicy{
+		"/public": {AllowedOrigins: []string{"*"}, AllowedMethods: []string{http.MethodPut}},
+		"/any":    anyOrigin,
+		"/mixed":  {AllowedOrigins: []string{"https://app.example.com", "*"}, AllowedMethods: []string{http.MethodPut}, AllowCredentials: true},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		origin     string
+		wantCode   int
+		wantOrigin string
+		wantMaxAge string
+		wantCreds  string
+	}{
+		{"preflight allowed", http.MethodOptions, "/private", "https://app.example.com", http.StatusNoContent, "https://app.example.com", "600", "true"},
+		{"preflight rejected", http.MethodOptions, "/private", "https://evil.com", http.StatusForbidden, "", "", ""},
+		{"preflight route override", http.MethodOptions, "/public", "https://evil.com", http.StatusNoContent, "*", "", ""},
+		{"request allowed", http.MethodPut, "/private", "https://app.example.com", http.StatusOK, "https://app.example.com", "", "true"},
+		{"request not allowed", http.MethodPut, "/private", "https://evil.com", http.StatusOK, "", "", ""},
+		{"preflight wildcard without credentials", http.MethodOptions, "/any", "https://evil.com", http.StatusNoContent, "*", "", ""},
+		{"request wildcard without credentials", http.MethodPut, "/any", "https://evil.com", http.StatusOK, "*", "", ""},
+		{"request listed origin along with wildcard", http.MethodPut, "/mixed", "https://app.example.com", http.StatusOK, "https://app.example.com", "", "true"},
+		{"request other origin along with wildcard", http.MethodPut, "/mixed", "https://evil.com", http.StatusOK, "*", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set(headerOrigin, tt.origin)
+			if tt.method == http.MethodOptions {
+				r.Header.Set(headerAccessControlRequestMethod, http.MethodPut)
+			}
+
+			w := httptest.NewRecorder()
+			i.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get(headerAllowOrigin); got != tt.wantOrigin {
+				t.Errorf("%s = %q, want %q", headerAllowOrigin, got, tt.wantOrigin)
+			}
+			if got := w.Header().Get(headerMaxAge); got != tt.wantMaxAge {
+				t.Errorf("%s = %q, want %q", headerMaxAge, got, tt.wantMaxAge)
+			}
+			if got := w.Header().Get(headerAllowCredentials); got != tt.wantCreds {
+				t.Errorf("%s = %q, want %q", headerAllowCredentials, got, tt.wantCreds)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package httplib
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// CSRFMode is a CSRF protection technique
+type CSRFMode int
+
+const (
+	// CSRFDoubleSubmit compares request token with the token cookie,
+	// cookie token is signed and bound to the session, so it can't be planted
+	CSRFDoubleSubmit CSRFMode = iota
+	// CSRFSynchronizer compares request token with the token kept server side per session
+	CSRFSynchronizer
+)
+
+const (
+	DefaultCSRFHeaderName = "X-CSRF-Token"
+	DefaultCSRFFieldName  = "csrf_token"
+	DefaultCSRFCookieName = "csrf_token"
+	csrfNonceLength       = 32
+)
+
+var (
+	ErrCSRFTokenMissing = fmt.Errorf("httplib: CSRF token is missing")
+	ErrCSRFTokenInvalid = fmt.Errorf("httplib: CSRF token is invalid")
+	ErrNoSession        = fmt.Errorf("httplib: request has no session")
+)
+
+// CSRFTokenStore keeps synchronizer tokens by session ID
+type CSRFTokenStore interface {
+	// Get returns session token, empty string if there is none
+	Get(ctx context.Context, sessionID string) (string, error)
+	Set(ctx context.Context, sessionID, token string) error
+}
+
+// MemoryCSRFStore is an in-memory CSRFTokenStore for single instance services and tests
+type MemoryCSRFStore struct {
+	mx     sync.RWMutex
+	tokens map[string]string
+}
+
+func NewMemoryCSRFStore() *MemoryCSRFStore {
+	return &MemoryCSRFStore{tokens: make(map[string]string)}
+}
+
+func (s *MemoryCSRFStore) Get(ctx context.Context, sessionID string) (string, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.tokens[sessionID], nil
+}
+
+func (s *MemoryCSRFStore) Set(ctx context.Context, sessionID, token string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.tokens[sessionID] = token
+	return nil
+}
+
+// CSRF protects unsafe requests from cross-site request forgery.
+// Token is expected in HeaderName header or FieldName form field.
+type CSRF struct {
+	Mode CSRFMode
+	// Key signs double submit tokens
+	Key []byte
+	// Cookie holds double submit token, it must not be HttpOnly to let scripts read it
+	Cookie *SessionCookies
+	// Store keeps synchronizer tokens
+	Store      CSRFTokenStore
+	HeaderName string
+	FieldName  string
+	// SessionID returns ID of the session request belongs to, tokens are rotated when it changes
+	SessionID func(r *http.Request) string
+}
+
+// NewCSRF returns double submit cookie CSRF protection, key must not be empty
+func NewCSRF(key []byte, sessionID func(r *http.Request) string) (*CSRF, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptySigningKey
+	}
+
+	cookie := NewSessionCookies(DefaultCSRFCookieName, 0)
+	cookie.HttpOnly = false
+
+	return &CSRF{
+		Mode:       CSRFDoubleSubmit,
+		Key:        key,
+		Cookie:     cookie,
+		HeaderName: DefaultCSRFHeaderName,
+		FieldName:  DefaultCSRFFieldName,
+		SessionID:  sessionID,
+	}, nil
+}
+
+// NewSynchronizerCSRF returns synchronizer token CSRF protection
+func NewSynchronizerCSRF(store CSRFTokenStore, sessionID func(r *http.Request) string) *CSRF {
+	return &CSRF{
+		Mode:       CSRFSynchronizer,
+		Store:      store,
+		HeaderName: DefaultCSRFHeaderName,
+		FieldName:  DefaultCSRFFieldName,
+		SessionID:  sessionID,
+	}
+}
+
+// CSRFTokenFromContext returns CSRF token of the session to be rendered in forms or sent to scripts
+func CSRFTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey).(string)
+	return token
+}
+
+// Middleware issues session token if there is none, and rejects unsafe requests
+// without valid token with 403 ApiError
+func (c *CSRF) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := c.sessionToken(w, r)
+		if err == ErrNoSession {
+			if isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+			} else {
+				RequestErrorResponseJSON(w, r, http.StatusForbidden, 0, err)
+			}
+			return
+		}
+		if err != nil {
+			RequestErrorResponseJSON(w, r, http.StatusInternalServerError, 0, err)
+			return
+		}
+
+		if !isSafeMethod(r.Method) {
+			if err := c.validate(r, token); err != nil {
+				RequestErrorResponseJSON(w, r, http.StatusForbidden, 0, err)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfTokenKey, token)))
+	})
+}
+
+// Rotate issues a new token for the request session, e.g. after login
+func (c *CSRF) Rotate(w http.ResponseWriter, r *http.Request) (string, error) {
+	sessionID := c.sessionID(r)
+
+	switch c.Mode {
+	case CSRFSynchronizer:
+		if sessionID == "" {
+			return "", ErrNoSession
+		}
+		token, err := randomToken()
+		if err != nil {
+			return "", err
+		}
+		if err := c.Store.Set(r.Context(), sessionID, token); err != nil {
+			return "", err
+		}
+		return token, nil
+
+	default:
+		if len(c.Key) == 0 {
+			return "", ErrEmptySigningKey
+		}
+		nonce, err := randomToken()
+		if err != nil {
+			return "", err
+		}
+		token := nonce + "." + c.tokenMac(sessionID, nonce)
+		if err := c.Cookie.Set(w, token); err != nil {
+			return "", err
+		}
+		return token, nil
+	}
+}
+
+// sessionToken returns current session token, issuing a new one if necessary
+func (c *CSRF) sessionToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	switch c.Mode {
+	case CSRFSynchronizer:
+		sessionID := c.sessionID(r)
+		if sessionID == "" {
+			return "", ErrNoSession
+		}
+		token, err := c.Store.Get(r.Context(), sessionID)
+		if err != nil {
+			return "", err
+		}
+		if token != "" {
+			return token, nil
+		}
+
+	default:
+		// token issued for another session is replaced, so it is rotated on login and logout
+		if token, err := c.Cookie.Get(r); err == nil && c.isBoundToSession(r, token) {
+			return token, nil
+		}
+	}
+
+	return c.Rotate(w, r)
+}
+
+func (c *CSRF) validate(r *http.Request, expected string) error {
+	got := r.Header.Get(valueOrDefault(c.HeaderName, DefaultCSRFHeaderName))
+	if got == "" {
+		got = r.PostFormValue(valueOrDefault(c.FieldName, DefaultCSRFFieldName))
+	}
+
+	if got == "" {
+		return ErrCSRFTokenMissing
+	}
+
+	if subtle.ConstantTimeCompare([]byte(got), []byte(expected)) != 1 {
+		return ErrCSRFTokenInvalid
+	}
+
+	return nil
+}
+
+func (c *CSRF) sessionID(r *http.Request) string {
+	if c.SessionID == nil {
+		return ""
+	}
+	return c.SessionID(r)
+}
+
+func (c *CSRF) isBoundToSession(r *http.Request, token string) bool {
+	idx := strings.IndexByte(token, '.')
+	if idx < 0 || len(c.Key) == 0 {
+		return false
+	}
+
+	return hmac.Equal([]byte(token[idx+1:]), []byte(c.tokenMac(c.sessionID(r), token[:idx])))
+}
+
+func (c *CSRF) tokenMac(sessionID, nonce string) string {
+	return base64.RawURLEncoding.EncodeToString(purposeSum(c.Key, signPurposeCSRF, []byte(sessionID+"|"+nonce)))
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, csrfNonceLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
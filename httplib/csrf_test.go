@@ -0,0 +1,140 @@
+package httplib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func csrfTestSession(r *http.Request) string {
+	return r.Header.Get("X-Session")
+}
+
+func TestCSRF_DoubleSubmit(t *testing.T) {
+	csrf, err := NewCSRF([]byte("secret"), csrfTestSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var token string
+	h := csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFTokenFromContext(r.Context())
+	}))
+
+	// safe request issues token cookie
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Session", "s1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusOK || len(cookies) != 1 || cookies[0].Value != token || token == "" {
+		t.Fatalf("GET status = %d, cookies = %v, token = %q", w.Code, cookies, token)
+	}
+
+	tests := []struct {
+		name    string
+		session string
+		header  string
+		form    string
+		code    int
+	}{
+		{name: "header", session: "s1", header: token, code: http.StatusOK},
+		{name: "form field", session: "s1", form: token, code: http.StatusOK},
+		{name: "missing", session: "s1", code: http.StatusForbidden},
+		{name: "mismatch", session: "s1", header: token + "x", code: http.StatusForbidden},
+		{name: "other session", session: "s2", header: token, code: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			if tt.form != "" {
+				body = url.Values{DefaultCSRFFieldName: {tt.form}}.Encode()
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.Header.Set("X-Session", tt.session)
+			r.Header.Set(DefaultCSRFHeaderName, tt.header)
+			r.AddCookie(cookies[0])
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestCSRF_Synchronizer(t *testing.T) {
+	store := NewMemoryCSRFStore()
+	c := NewSynchronizerCSRF(store, csrfTestSession)
+	h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(method, session, token string) int {
+		r := httptest.NewRequest(method, "/", nil)
+		r.Header.Set("X-Session", session)
+		r.Header.Set(DefaultCSRFHeaderName, token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve(http.MethodGet, "", ""); code != http.StatusOK {
+		t.Errorf("GET without session status = %d, want %d", code, http.StatusOK)
+	}
+	if code := serve(http.MethodGet, "s1", ""); code != http.StatusOK {
+		t.Errorf("GET status = %d, want %d", code, http.StatusOK)
+	}
+
+	token, _ := store.Get(context.Background(), "s1")
+	if token == "" {
+		t.Fatal("token is not issued for session")
+	}
+
+	if code := serve(http.MethodDelete, "s1", token); code != http.StatusOK {
+		t.Errorf("DELETE status = %d, want %d", code, http.StatusOK)
+	}
+	if code := serve(http.MethodDelete, "s2", token); code != http.StatusForbidden {
+		t.Errorf("DELETE with other session status = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := serve(http.MethodDelete, "", token); code != http.StatusForbidden {
+		t.Errorf("DELETE without session status = %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestCSRF_keys(t *testing.T) {
+	if _, err := NewCSRF(nil, csrfTestSession); err != ErrEmptySigningKey {
+		t.Errorf("NewCSRF(nil) error = %v, want %v", err, ErrEmptySigningKey)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Session", "s1")
+	if _, err := (&CSRF{Cookie: NewSessionCookies(DefaultCSRFCookieName, 0)}).Rotate(httptest.NewRecorder(), r); err != ErrEmptySigningKey {
+		t.Errorf("Rotate() with empty key error = %v, want %v", err, ErrEmptySigningKey)
+	}
+
+	// cursor signed with the same key is not a valid token
+	key := []byte("shared secret")
+	csrf, _ := NewCSRF(key, csrfTestSession)
+	codec, _ := NewCursorCodec(key)
+	cursor, err := codec.Encode(Cursor{Values: []interface{}{"s1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csrf.isBoundToSession(r, cursor) {
+		t.Error("cursor is accepted as CSRF token")
+	}
+
+	token, err := csrf.Rotate(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !csrf.isBoundToSession(r, token) {
+		t.Error("issued token is not bound to session")
+	}
+}
@@ -0,0 +1,122 @@
+package httplib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	CursorNext = "next"
+	CursorPrev = "prev"
+)
+
+var ErrInvalidCursor = fmt.Errorf("httplib: invalid cursor")
+
+// Cursor points to a position in keyset paginated list.
+// Values are sort key values of the boundary row, in the order of sort columns.
+type Cursor struct {
+	Values    []interface{} `json:"v"`
+	Direction string        `json:"d"`
+}
+
+// IsBackward reports whether cursor points to the previous page
+func (c Cursor) IsBackward() bool {
+	return c.Direction == CursorPrev
+}
+
+// CursorCodec encodes cursors into opaque signed strings,
+// so clients are unable to forge them
+type CursorCodec struct {
+	Key []byte
+}
+
+// NewCursorCodec returns codec signing cursors with key, which must not be empty
+func NewCursorCodec(key []byte) (*CursorCodec, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptySigningKey
+	}
+	return &CursorCodec{Key: key}, nil
+}
+
+// Encode returns signed base64 representation of the cursor
+func (c *CursorCodec) Encode(cur Cursor) (string, error) {
+	if cur.Direction == "" {
+		cur.Direction = CursorNext
+	}
+
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+
+	return signPayload(c.Key, signPurposeCursor, payload)
+}
+
+// Decode verifies cursor signature and returns decoded cursor.
+// Numeric values are decoded as json.Number to keep precision of large identifiers.
+func (c *CursorCodec) Decode(s string) (*Cursor, error) {
+	payload, err := verifyPayload(c.Key, signPurposeCursor, s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	cur := new(Cursor)
+	if err := dec.Decode(cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if cur.Direction != CursorNext && cur.Direction != CursorPrev {
+		return nil, ErrInvalidCursor
+	}
+
+	return cur, nil
+}
+
+// GetCursorFromRequest returns cursor and limit from "cursor" and "limit" request parameters.
+// Cursor is nil if request asks for the first page.
+func (c *CursorCodec) GetCursorFromRequest(r *http.Request) (*Cursor, int, error) {
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	limit, _ = GetLimitAndOffsetFromPageNumber(1, limit)
+
+	value := r.FormValue("cursor")
+	if value == "" {
+		return nil, limit, nil
+	}
+
+	cur, err := c.Decode(value)
+	if err != nil {
+		return nil, limit, err
+	}
+
+	return cur, limit, nil
+}
+
+// ListResult returns CursorListResult with cursors pointing before the first
+// and after the last row of results. first and last are sort key values of these rows.
+func (c *CursorCodec) ListResult(results interface{}, count int32, first, last []interface{}, hasPrev, hasNext bool) (*CursorListResult, error) {
+	res := &CursorListResult{
+		Results: results,
+		Count:   count,
+	}
+
+	var err error
+	if hasNext && len(last) > 0 {
+		if res.NextCursor, err = c.Encode(Cursor{Values: last, Direction: CursorNext}); err != nil {
+			return nil, err
+		}
+	}
+
+	if hasPrev && len(first) > 0 {
+		if res.PrevCursor, err = c.Encode(Cursor{Values: first, Direction: CursorPrev}); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package httplib
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec, err := NewCursorCodec([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := codec.Encode(Cursor{Values: []interface{}{"2022-05-19", int64(9007199254740993)}})
+	if err != nil {
+		t.Fatalf("Encode() error = %s", err)
+	}
+
+	r := httptest.NewRequest("GET", "/?limit=500&cursor="+s, nil)
+	cur, limit, err := codec.GetCursorFromRequest(r)
+	if err != nil {
+		t.Fatalf("GetCursorFromRequest() error = %s", err)
+	}
+	if limit != DefaultPaginator.MaxLimit {
+		t.Errorf("limit = %d, want %d", limit, DefaultPaginator.MaxLimit)
+	}
+	if cur.IsBackward() {
+		t.Errorf("cursor direction = %s, want %s", cur.Direction, CursorNext)
+	}
+	if id, ok := cur.Values[1].(json.Number); !ok || id.String() != "9007199254740993" {
+		t.Errorf("cursor id value = %v, want 9007199254740993", cur.Values[1])
+	}
+
+	if _, err := (&CursorCodec{Key: []byte("other")}).Decode(s); err != ErrInvalidCursor {
+		t.Errorf("Decode() with other key error = %v, want %v", err, ErrInvalidCursor)
+	}
+	if _, err := codec.Decode("e30." + s[len(s)-10:]); err != ErrInvalidCursor {
+		t.Errorf("Decode() of tampered cursor error = %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func TestCursorCodec_keys(t *testing.T) {
+	if _, err := NewCursorCodec(nil); err != ErrEmptySigningKey {
+		t.Errorf("NewCursorCodec(nil) error = %v, want %v", err, ErrEmptySigningKey)
+	}
+	if _, err := (&CursorCodec{}).Encode(Cursor{}); err != ErrEmptySigningKey {
+		t.Errorf("Encode() with empty key error = %v, want %v", err, ErrEmptySigningKey)
+	}
+
+	// value signed with the same key for other purpose is not a valid cursor
+	payload := []byte(`{"values":[1],"direction":"next"}`)
+	signed, err := signPayload([]byte("secret"), signPurposeAuth, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codec := &CursorCodec{Key: []byte("secret")}
+	if _, err := codec.Decode(signed); err != ErrInvalidCursor {
+		t.Errorf("Decode() of value signed for auth error = %v, want %v", err, ErrInvalidCursor)
+	}
+}
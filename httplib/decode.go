@@ -0,0 +1,131 @@
+package httplib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodySize limits request body size read by DecodeJSON
+const DefaultMaxBodySize int64 = 1 << 20
+
+// DecodeError describes malformed request body
+type DecodeError struct {
+	Status  int
+	Message string
+}
+
+func (e *DecodeError) Error() string {
+	return e.Message
+}
+
+// JSONDecoder decodes and validates JSON request bodies
+type JSONDecoder struct {
+	// MaxBodySize defaults to DefaultMaxBodySize
+	MaxBodySize int64
+	// AllowUnknownFields disables rejection of fields not present in destination struct
+	AllowUnknownFields bool
+	// SkipValidation disables struct tag validation of decoded value
+	SkipValidation bool
+}
+
+// DecodeJSON decodes request body into dst with default JSONDecoder
+func DecodeJSON(r *http.Request, dst interface{}) error {
+	return JSONDecoder{}.Decode(r, dst)
+}
+
+// Decode decodes request body into dst and validates it by `validate` struct tags.
+// It returns *DecodeError if body is malformed, or ValidationErrors if it is invalid.
+func (d JSONDecoder) Decode(r *http.Request, dst interface{}) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return &DecodeError{
+				Status:  http.StatusUnsupportedMediaType,
+				Message: "Content-Type header is not application/json",
+			}
+		}
+	}
+
+	maxSize := d.MaxBodySize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBodySize
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxSize))
+	if !d.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err, maxSize)
+	}
+
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return &DecodeError{
+			Status:  http.StatusBadRequest,
+			Message: "request body must only contain a single JSON value",
+		}
+	}
+
+	if d.SkipValidation {
+		return nil
+	}
+
+	return Validate(dst)
+}
+
+func decodeError(err error, maxSize int64) *DecodeError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	msg := err.Error()
+	switch {
+	case errors.As(err, &syntaxErr):
+		msg = fmt.Sprintf("request body contains badly-formed JSON (at position %d)", syntaxErr.Offset)
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		msg = "request body contains badly-formed JSON"
+
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			msg = fmt.Sprintf("request body contains an invalid value for the %q field (at position %d), expected %s",
+				typeErr.Field, typeErr.Offset, typeErr.Type)
+		} else {
+			msg = fmt.Sprintf("request body contains an invalid value (at position %d), expected %s",
+				typeErr.Offset, typeErr.Type)
+		}
+
+	case strings.HasPrefix(msg, "json: unknown field "):
+		msg = fmt.Sprintf("request body contains unknown field %s", strings.TrimPrefix(msg, "json: unknown field "))
+
+	case errors.Is(err, io.EOF):
+		msg = "request body must not be empty"
+
+	// http.MaxBytesReader error has no exported type in go1.17
+	case msg == "http: request body too large":
+		return &DecodeError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("request body must not be larger than %d bytes", maxSize),
+		}
+	}
+
+	return &DecodeError{Status: http.StatusBadRequest, Message: msg}
+}
+
+// DecodeErrorResponseJSON responds with ApiError for DecodeJSON error,
+// listing every invalid field for ValidationErrors
+func DecodeErrorResponseJSON(w http.ResponseWriter, r *http.Request, err error) {
+	var decodeErr *DecodeError
+	var validationErrs ValidationErrors
+	if !errors.As(err, &decodeErr) && !errors.As(err, &validationErrs) {
+		RequestErrorResponseJSON(w, r, http.StatusBadRequest, 0, err)
+		return
+	}
+
+	ErrorResponse(w, r, err)
+}
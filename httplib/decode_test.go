@@ -0,0 +1,96 @@
+package httplib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type testSignup struct {
+	Name      string        `json:"name" validate:"required,min=2,max=8"`
+	Email     string        `json:"email" validate:"required,email"`
+	Phone     string        `json:"phone,omitempty" validate:"phone"`
+	Age       int           `json:"age" validate:"min=18"`
+	Tags      []string      `json:"tags" validate:"max=2"`
+	Addresses []testAddress `json:"addresses"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		message     string
+		fields      []string
+	}{
+		{name: "valid", body: `{"name":"John","email":"john@rovergulf.net","age":30,"addresses":[{"city":"Moscow"}]}`},
+		{name: "content type", contentType: "text/plain", body: `{}`, status: http.StatusUnsupportedMediaType},
+		{name: "syntax", body: `{"name":"John",}`, status: http.StatusBadRequest, message: "badly-formed JSON (at position 16)"},
+		{name: "type", body: `{"age":"thirty"}`, status: http.StatusBadRequest, message: `invalid value for the "age" field`},
+		{name: "unknown field", body: `{"nickname":"j"}`, status: http.StatusBadRequest, message: `unknown field "nickname"`},
+		{name: "empty", body: ``, status: http.StatusBadRequest, message: "must not be empty"},
+		{name: "multiple values", body: `{} {}`, status: http.StatusBadRequest, message: "single JSON value"},
+		{name: "too large", body: `{"name":"` + strings.Repeat("a", int(DefaultMaxBodySize)) + `"}`, status: http.StatusRequestEntityTooLarge},
+		{
+			name:   "min on zero",
+			body:   `{"name":"John","email":"john@rovergulf.net","age":0}`,
+			status: http.StatusBadRequest,
+			fields: []string{"age"},
+		},
+		{
+			name:   "validation",
+			body:   `{"name":"J","email":"john@","phone":"123","age":16,"tags":["a","b","c"],"addresses":[{"city":"Moscow"},{}]}`,
+			status: http.StatusBadRequest,
+			fields: []string{"name", "email", "phone", "age", "tags", "addresses[1].city"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json; charset=utf-8")
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var dst testSignup
+			err := DecodeJSON(r, &dst)
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("DecodeJSON() error = %s", err)
+				}
+				return
+			}
+
+			w := httptest.NewRecorder()
+			DecodeErrorResponseJSON(w, r, err)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d (%v)", w.Code, tt.status, err)
+			}
+
+			var apiErr ApiError
+			if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+				t.Fatalf("unable to decode response: %s", err)
+			}
+			if !strings.Contains(apiErr.Detail, tt.message) {
+				t.Errorf("detail = %q, want it to contain %q", apiErr.Detail, tt.message)
+			}
+
+			var fields []string
+			for _, fe := range apiErr.Errors {
+				fields = append(fields, fe.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("invalid fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
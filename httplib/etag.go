@@ -0,0 +1,170 @@
+package httplib
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rovergulf/utils"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	headerETag              = "ETag"
+	headerLastModified      = "Last-Modified"
+	headerIfMatch           = "If-Match"
+	headerIfNoneMatch       = "If-None-Match"
+	headerIfModifiedSince   = "If-Modified-Since"
+	headerIfUnmodifiedSince = "If-Unmodified-Since"
+)
+
+var ErrPreconditionFailed = fmt.Errorf("resource has been modified")
+
+// ETag returns strong entity tag of the payload, that is quoted hex SHA-256 sum
+func ETag(payload []byte) string {
+	return `"` + utils.GenerateHash(payload) + `"`
+}
+
+// ETagOf returns entity tag of v JSON representation, as it is sent by ConditionalResponseJSON
+func ETagOf(v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return ETag(payload), nil
+}
+
+// ResponseJSONWithETag is ConditionalResponseJSON for resources without modification time
+func ResponseJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	ConditionalResponseJSON(w, r, v, time.Time{})
+}
+
+// ConditionalResponseJSON sends OK JSON response with ETag and Last-Modified headers,
+// or 304 without body if request If-None-Match or If-Modified-Since shows client has it already.
+// Zero lastModified omits Last-Modified header.
+func ConditionalResponseJSON(w http.ResponseWriter, r *http.Request, v interface{}, lastModified time.Time) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		RequestErrorResponseJSON(w, r, http.StatusInternalServerError, 0, err)
+		return
+	}
+
+	etag := ETag(payload)
+	h := w.Header()
+	h.Set(headerETag, etag)
+	if !lastModified.IsZero() {
+		h.Set(headerLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if isNotModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set(headerContentType, "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(payload)
+	}
+}
+
+// isNotModified evaluates If-None-Match, or If-Modified-Since if the former is absent, as RFC 7232 defines
+func isNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get(headerIfNoneMatch); inm != "" {
+		return matchETag(inm, etag, true)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get(headerIfModifiedSince))
+	if err != nil {
+		return false
+	}
+
+	// HTTP dates have a second precision
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// CheckPreconditions evaluates If-Match and If-Unmodified-Since headers of state changing request
+// against current entity tag and modification time of the resource, empty etag means resource does not exist.
+// It responds with 412 and returns false if any precondition fails, so handler must not proceed.
+func CheckPreconditions(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	if ifMatch := r.Header.Get(headerIfMatch); ifMatch != "" {
+		if etag == "" || !matchETag(ifMatch, etag, false) {
+			RequestErrorResponseJSON(w, r, http.StatusPreconditionFailed, 0, ErrPreconditionFailed)
+			return false
+		}
+		return true
+	}
+
+	if lastModified.IsZero() {
+		return true
+	}
+
+	since, err := http.ParseTime(r.Header.Get(headerIfUnmodifiedSince))
+	if err != nil {
+		return true
+	}
+
+	if lastModified.Truncate(time.Second).After(since) {
+		RequestErrorResponseJSON(w, r, http.StatusPreconditionFailed, 0, ErrPreconditionFailed)
+		return false
+	}
+
+	return true
+}
+
+// matchETag reports whether etag is in the list of header entity tags.
+// Weak comparison ignores W/ prefix, strong comparison never matches weak tags.
+// Tags of compressed representations, as CompressionMiddleware sends them, match etag as well.
+func matchETag(header, etag string, weak bool) bool {
+	if !weak && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+
+		if current := strings.TrimPrefix(etag, "W/"); tag == current || trimETagEncoding(tag) == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodedETag returns strong etag specific to content coding, so compressed and identity
+// representations have different tags, but remain strong to be used with If-Match.
+// Weak tags are returned as is, since they are equivalent regardless of coding.
+func encodedETag(etag, encoding string) string {
+	if strings.HasPrefix(etag, "W/") || len(etag) < 2 || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return etag[:len(etag)-1] + "-" + encoding + `"`
+}
+
+// trimETagEncoding returns etag without content coding suffix added by encodedETag
+func trimETagEncoding(etag string) string {
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, EncodingZstd} {
+		if suffix := "-" + encoding + `"`; strings.HasSuffix(etag, suffix) {
+			return etag[:len(etag)-len(suffix)] + `"`
+		}
+	}
+	return etag
+}
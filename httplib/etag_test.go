@@ -0,0 +1,93 @@
+package httplib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConditionalResponseJSON(t *testing.T) {
+	v := map[string]string{"name": "rovergulf"}
+	etag, err := ETagOf(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2022, 5, 19, 12, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		want   int
+	}{
+		{"no conditions", http.MethodGet, nil, http.StatusOK},
+		{"etag matches", http.MethodGet, map[string]string{headerIfNoneMatch: `"other", ` + etag}, http.StatusNotModified},
+		{"weak etag matches", http.MethodHead, map[string]string{headerIfNoneMatch: "W/" + etag}, http.StatusNotModified},
+		{"etag differs", http.MethodGet, map[string]string{headerIfNoneMatch: `"other"`}, http.StatusOK},
+		{"not modified since", http.MethodGet, map[string]string{headerIfModifiedSince: modified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", http.MethodGet, map[string]string{headerIfModifiedSince: modified.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		{"etag takes precedence", http.MethodGet, map[string]string{headerIfNoneMatch: `"other"`, headerIfModifiedSince: modified.Format(http.TimeFormat)}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			ConditionalResponseJSON(w, r, v, modified)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get(headerETag); got != etag {
+				t.Errorf("ETag = %s, want %s", got, etag)
+			}
+			if w.Code == http.StatusNotModified && w.Body.Len() > 0 {
+				t.Errorf("304 response has body %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	etag := ETag([]byte("current"))
+	modified := time.Date(2022, 5, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		etag   string
+		want   bool
+	}{
+		{"no conditions", nil, etag, true},
+		{"etag matches", map[string]string{headerIfMatch: etag}, etag, true},
+		{"any etag", map[string]string{headerIfMatch: "*"}, etag, true},
+		{"resource does not exist", map[string]string{headerIfMatch: "*"}, "", false},
+		{"etag differs", map[string]string{headerIfMatch: `"stale"`}, etag, false},
+		{"weak etag never matches", map[string]string{headerIfMatch: "W/" + etag}, etag, false},
+		{"compressed etag matches", map[string]string{headerIfMatch: encodedETag(etag, EncodingGzip)}, etag, true},
+		{"unmodified", map[string]string{headerIfUnmodifiedSince: modified.Format(http.TimeFormat)}, etag, true},
+		{"modified", map[string]string{headerIfUnmodifiedSince: modified.Add(-time.Minute).Format(http.TimeFormat)}, etag, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			if got := CheckPreconditions(w, r, tt.etag, modified); got != tt.want {
+				t.Errorf("CheckPreconditions() = %v, want %v", got, tt.want)
+			}
+			if !tt.want && w.Code != http.StatusPreconditionFailed {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package httplib
 
 import (
-	"context"
 	"crypto/tls"
 	"github.com/gorilla/mux"
 	"github.com/opentracing/opentracing-go"
@@ -9,17 +8,33 @@ import (
 	"github.com/rovergulf/utils/tracing"
 	"go.uber.org/zap"
 	"net/http"
-	"strings"
+	"sync"
 )
 
 // Interceptor
 type Interceptor struct {
-	Router  *mux.Router
-	Tracer  opentracing.Tracer
-	Logger  *zap.SugaredLogger
-	tlsConf *tls.Config
+	Router *mux.Router
+	Tracer opentracing.Tracer
+	Logger *zap.SugaredLogger
+	// CORS is a policy applied to cross-origin requests, nil disables CORS handling
+	CORS *CORSPolicy
+	// RouteCORS overrides CORS policy for routes, keyed by mux route path template
+	RouteCORS map[string]*CORSPolicy
+	// AccessLog configures access log written by LoggingMiddleware
+	AccessLog AccessLogConfig
+	// Development enables panic details in responses written by RecoveryMiddleware
+	Development bool
+	tlsConf     *tls.Config
+
+	middlewares []Middleware
+	// handler is a middlewares chain built on the first request
+	handler     http.Handler
+	handlerOnce sync.Once
 }
 
+// Middleware wraps http.Handler with additional behavior
+type Middleware = func(http.Handler) http.Handler
+
 const (
 	headersSep = ", "
 )
@@ -36,6 +51,7 @@ var allowedHeaders = []string{
 	"Authorization",
 	"X-CSRF-Token",
 	"X-Requested-With",
+	HeaderRequestID,
 }
 
 var allowedMethods = []string{
@@ -47,9 +63,12 @@ var allowedMethods = []string{
 	"DELETE",
 }
 
+// NewInterceptor returns Interceptor with request context, CORS, tracing,
+// logging and recovery middlewares installed
 func NewInterceptor(lg *zap.SugaredLogger, j *tracing.Jaeger, tlsConf *tls.Config) *Interceptor {
 	i := &Interceptor{
 		Logger:  lg,
+		CORS:    DefaultCORSPolicy(),
 		tlsConf: tlsConf,
 	}
 
@@ -57,46 +76,88 @@ func NewInterceptor(lg *zap.SugaredLogger, j *tracing.Jaeger, tlsConf *tls.Confi
 		i.Tracer = j.Tracer
 	}
 
+	i.Use(i.ContextMiddleware, i.CORSMiddleware, i.TracingMiddleware, i.LoggingMiddleware, i.RecoveryMiddleware)
+
 	return i
 }
 
-// ServeHTTP
+// Use appends middlewares to the interceptor chain.
+// Middlewares are executed in the order they were added, so the first one
+// is the outermost and sees the request before any other.
+// It is not safe to call Use while interceptor is serving requests.
+func (i *Interceptor) Use(mw ...func(http.Handler) http.Handler) {
+	i.middlewares = append(i.middlewares, mw...)
+	i.handlerOnce = sync.Once{}
+}
+
+// Handler returns Router wrapped into registered middlewares chain
+func (i *Interceptor) Handler() http.Handler {
+	var h http.Handler = http.NotFoundHandler()
+	if i.Router != nil {
+		h = i.Router
+	}
+
+	for idx := len(i.middlewares) - 1; idx >= 0; idx-- {
+		h = i.middlewares[idx](h)
+	}
+
+	return h
+}
+
+// ServeHTTP serves request with the chain built by Handler on the first request,
+// so Router has to be set before interceptor starts serving
 func (i *Interceptor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	// Set request headers for AJAX requests
-	if origin := r.Header.Get("Origin"); origin != "" {
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
-		w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, headersSep))
-		w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, headersSep))
+	i.handlerOnce.Do(func() {
+		i.handler = i.Handler()
+	})
+	i.handler.ServeHTTP(w, r)
+}
+
+// corsPolicy returns CORS policy for the route matching request, if any
+func (i *Interceptor) corsPolicy(r *http.Request) *CORSPolicy {
+	if len(i.RouteCORS) == 0 {
+		return i.CORS
+	}
+
+	req := r
+	if isPreflightRequest(r) {
+		// preflight has to be matched against the method of actual request
+		rr := *r
+		rr.Method = r.Header.Get(headerAccessControlRequestMethod)
+		req = &rr
 	}
 
-	// handle preflight request
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
+	if policy, ok := i.RouteCORS[i.routeTemplate(req)]; ok {
+		return policy
 	}
 
-	ctx = context.WithValue(ctx, "host", r.Host)
-	ctx = context.WithValue(ctx, "path", r.URL.Path)
-	ctx = context.WithValue(ctx, "remote_addr", r.RemoteAddr)
-	ctx = context.WithValue(ctx, "x_forwarded_for", r.Header.Get(ipaddr.XForwardedFor))
-	ctx = context.WithValue(ctx, "cf_connecting_ip", r.Header.Get(ipaddr.CFConnectingIp))
-
-	if i.Tracer != nil {
-		span := i.Tracer.StartSpan(strings.TrimPrefix(r.URL.Path, "/"))
-		span.SetTag("host", r.Host)
-		span.SetTag("method", r.Method)
-		span.SetTag("path", r.URL.Path)
-		span.SetTag("query", r.URL.RawQuery)
-		span.SetTag("remote_addr", r.RemoteAddr)
-		span.SetTag("x_forwarded_for", r.Header.Get(ipaddr.XForwardedFor))
-		defer span.Finish()
-		ctx = opentracing.ContextWithSpan(ctx, span)
+	return i.CORS
+}
+
+// requestRoute returns route template resolved by ContextMiddleware,
+// or matches request against Router if it is not available
+func (i *Interceptor) requestRoute(r *http.Request) string {
+	if info, ok := RequestInfoFromContext(r.Context()); ok {
+		return info.Route
 	}
+	return i.routeTemplate(r)
+}
 
-	i.Logger.Infow("Handling request", "method", r.Method, "path", r.URL.Path, "query", r.URL.RawQuery)
+// routeTemplate returns path template of the mux route matching request
+func (i *Interceptor) routeTemplate(r *http.Request) string {
+	if i.Router == nil {
+		return ""
+	}
+
+	var match mux.RouteMatch
+	if !i.Router.Match(r, &match) || match.Route == nil {
+		return ""
+	}
+
+	tpl, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
 
-	i.Router.ServeHTTP(w, r.WithContext(ctx))
+	return tpl
 }
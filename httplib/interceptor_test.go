@@ -0,0 +1,97 @@
+package httplib
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInterceptor_Use(t *testing.T) {
+	var trace []string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				trace = append(trace, name+">")
+				next.ServeHTTP(w, r)
+				trace = append(trace, "<"+name)
+			})
+		}
+	}
+
+	i := new(Interceptor)
+	i.Use(mw("a"), mw("b"))
+	i.Use(mw("c"))
+
+	i.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := "a> b> c> <c <b <a"
+	if got := strings.Join(trace, " "); got != want {
+		t.Errorf("middlewares order = %q, want %q", got, want)
+	}
+}
+
+func TestInterceptor_ServeHTTPBuildsChainOnce(t *testing.T) {
+	var built int
+	i := new(Interceptor)
+	i.Use(func(next http.Handler) http.Handler {
+		built++
+		return next
+	})
+
+	for n := 0; n < 3; n++ {
+		i.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	if built != 1 {
+		t.Errorf("middleware constructor called %d times, want 1", built)
+	}
+
+	var called bool
+	i.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			next.ServeHTTP(w, r)
+		})
+	})
+	i.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !called || built != 2 {
+		t.Errorf("chain is not rebuilt after Use, called %v, built %d times", called, built)
+	}
+}
+
+func TestInterceptor_RecoveryMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	i := NewInterceptor(zap.NewNop().Sugar(), nil, nil)
+	i.Router = router
+
+	for _, dev := range []bool{false, true} {
+		i.Development = dev
+
+		r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+		r.Header.Set(HeaderRequestID, "test-request")
+		w := httptest.NewRecorder()
+		i.ServeHTTP(w, r)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+		}
+
+		var apiErr ApiError
+		if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+			t.Fatalf("unable to decode response: %s", err)
+		}
+		if apiErr.RequestId != "test-request" {
+			t.Errorf("request_id = %q, want %q", apiErr.RequestId, "test-request")
+		}
+		if hasStack := apiErr.Stack != ""; hasStack != dev {
+			t.Errorf("development = %v, but stack presence = %v", dev, hasStack)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package httplib
+
+import (
+	"fmt"
+	"github.com/rovergulf/utils/ipaddr"
+	"github.com/rovergulf/utils/response"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+)
+
+var ErrIPAddressDenied = fmt.Errorf("access from this IP address is not allowed")
+
+// IPFilterConfig configures IPFilterMiddleware
+type IPFilterConfig struct {
+	// Resolver resolves client address, connection address is used if it is nil
+	Resolver *ipaddr.Resolver
+	// Allow lists networks allowed to access, any network is allowed if it is nil
+	Allow *ipaddr.CIDRSet
+	// Deny lists networks denied to access, it takes precedence over Allow
+	Deny   *ipaddr.CIDRSet
+	Logger *zap.SugaredLogger
+}
+
+// IPFilterMiddleware rejects requests from denied or not allowed addresses with 403 ApiError,
+// logging rule request matched
+func IPFilterMiddleware(conf IPFilterConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, source := conf.clientIP(r)
+
+			if conf.Deny != nil {
+				if rule, ok := conf.Deny.Match(ip); ok {
+					conf.logDenied(r, ip, source, "deny", rule)
+					RequestErrorResponseJSON(w, r, http.StatusForbidden, response.ErrCodePermissionDenied, ErrIPAddressDenied)
+					return
+				}
+			}
+
+			if conf.Allow != nil {
+				rule, ok := conf.Allow.Match(ip)
+				if !ok {
+					conf.logDenied(r, ip, source, "allow", nil)
+					RequestErrorResponseJSON(w, r, http.StatusForbidden, response.ErrCodePermissionDenied, ErrIPAddressDenied)
+					return
+				}
+
+				if conf.Logger != nil {
+					conf.Logger.Debugw("IP address allowed",
+						"request_id", RequestIDFromContext(r.Context()),
+						"ip", ip.String(),
+						"source", source,
+						"rule", rule.String(),
+					)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (c IPFilterConfig) clientIP(r *http.Request) (net.IP, string) {
+	if c.Resolver != nil {
+		return c.Resolver.Resolve(r)
+	}
+	return ipaddr.ParseIP(r.RemoteAddr), ipaddr.RemoteAddr
+}
+
+// logDenied logs rejected request, rule is nil if address is not in allow list
+func (c IPFilterConfig) logDenied(r *http.Request, ip net.IP, source, list string, rule *net.IPNet) {
+	if c.Logger == nil {
+		return
+	}
+
+	matched := ""
+	if rule != nil {
+		matched = rule.String()
+	}
+
+	c.Logger.Warnw("IP address denied",
+		"request_id", RequestIDFromContext(r.Context()),
+		"ip", ip.String(),
+		"source", source,
+		"list", list,
+		"rule", matched,
+		"path", r.URL.Path,
+	)
+}
@@ -0,0 +1,35 @@
+package httplib
+
+import (
+	"github.com/rovergulf/utils/ipaddr"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilterMiddleware(t *testing.T) {
+	allow, _ := ipaddr.NewCIDRSet("10.0.0.0/8")
+	deny, _ := ipaddr.NewCIDRSet("10.6.6.0/24")
+	handler := IPFilterMiddleware(IPFilterConfig{Allow: allow, Deny: deny})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		remoteAddr string
+		want       int
+	}{
+		{"10.1.1.1:1234", http.StatusOK},
+		{"10.6.6.6:1234", http.StatusForbidden},
+		{"203.0.113.1:1234", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		r.RemoteAddr = tt.remoteAddr
+		r.Header.Set(ipaddr.XForwardedFor, "10.1.1.1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.remoteAddr, w.Code, tt.want)
+		}
+	}
+}
@@ -2,16 +2,54 @@ package httplib
 
 import (
 	"encoding/json"
+	"errors"
+	"github.com/rovergulf/utils/response"
 	"net/http"
 )
 
-func makeError(code int, err error) *ApiError {
-	return NewApiError(code, err.Error())
+func makeError(httpCode int, code int, err error) *ApiError {
+	return response.NewProblem(httpCode, code, err.Error())
 }
 
 // Sends error http response
 func ErrorResponseJSON(w http.ResponseWriter, httpCode int, internalCode int, err error) {
-	writeJSON(w, httpCode, makeError(internalCode, err))
+	makeError(httpCode, internalCode, err).Write(w)
+}
+
+// Sends error http response with ID of the request
+func RequestErrorResponseJSON(w http.ResponseWriter, r *http.Request, httpCode int, internalCode int, err error) {
+	writeProblem(w, r, makeError(httpCode, internalCode, err))
+}
+
+// ErrorResponse sends problem details for err, with status resolved by response.FromError.
+// DecodeError and ValidationErrors are reported as client errors.
+func ErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var decodeErr *DecodeError
+	var validationErrs ValidationErrors
+
+	var apiErr *ApiError
+	switch {
+	case errors.As(err, &decodeErr):
+		apiErr = makeError(decodeErr.Status, response.ErrCodeInvalidArguments, decodeErr)
+	case errors.As(err, &validationErrs):
+		apiErr = makeError(http.StatusBadRequest, response.ErrCodeInvalidArguments, validationErrs)
+		apiErr.Errors = validationErrs
+	default:
+		apiErr = response.FromError(err)
+	}
+
+	writeProblem(w, r, apiErr)
+}
+
+// writeProblem sends problem details identifying request they occurred at
+func writeProblem(w http.ResponseWriter, r *http.Request, apiErr *ApiError) {
+	if apiErr.Instance == "" {
+		apiErr.Instance = r.URL.Path
+	}
+	if apiErr.RequestId == "" {
+		apiErr.RequestId = RequestIDFromContext(r.Context())
+	}
+	apiErr.Write(w)
 }
 
 // Sends OK JSON response
@@ -32,3 +70,9 @@ func writeJSON(w http.ResponseWriter, httpCode int, v interface{}) {
 	w.WriteHeader(httpCode)
 	w.Write(response)
 }
+
+// Respond writes v in the media type negotiated by request Accept header,
+// see response.DefaultNegotiator for supported media types
+func Respond(w http.ResponseWriter, r *http.Request, httpCode int, v interface{}) error {
+	return response.DefaultNegotiator.Write(w, r, httpCode, v)
+}
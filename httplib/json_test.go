@@ -0,0 +1,34 @@
+package httplib
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rovergulf/utils/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorResponse_SharedError(t *testing.T) {
+	errNotFound := response.NewApiError(response.ErrCodeNotFound, "no such item")
+
+	for _, id := range []string{"a", "b"} {
+		r := httptest.NewRequest(http.MethodGet, "/"+id, nil)
+		r = r.WithContext(ContextWithRequestInfo(r.Context(), &RequestInfo{ID: id}))
+		w := httptest.NewRecorder()
+
+		ErrorResponse(w, r, fmt.Errorf("lookup: %w", errNotFound))
+
+		var body ApiError
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Instance != "/"+id || body.RequestId != id {
+			t.Errorf("request %s: instance = %q, request_id = %q", id, body.Instance, body.RequestId)
+		}
+	}
+
+	if errNotFound.Instance != "" || errNotFound.RequestId != "" {
+		t.Errorf("shared error is modified: %+v", errNotFound)
+	}
+}
@@ -0,0 +1,189 @@
+package httplib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sync"
+)
+
+// KeySet is a set of JWT keys looked up by ID, it is safe for concurrent use
+type KeySet struct {
+	mx   sync.RWMutex
+	keys map[string]*JWTKey
+	path string
+}
+
+// NewKeySet returns KeySet containing specified keys
+func NewKeySet(keys ...JWTKey) *KeySet {
+	ks := new(KeySet)
+	ks.set(keys)
+	return ks
+}
+
+// LoadJWKSFile returns KeySet containing public and symmetric keys of the local JWKS file.
+// Keys could be rotated with Reload after the file is updated.
+func LoadJWKSFile(path string) (*KeySet, error) {
+	ks := &KeySet{path: path}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Reload replaces keys with ones from the JWKS file
+func (ks *KeySet) Reload() error {
+	if ks.path == "" {
+		return fmt.Errorf("httplib: key set is not loaded from file")
+	}
+
+	data, err := ioutil.ReadFile(ks.path)
+	if err != nil {
+		return err
+	}
+
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	ks.set(keys)
+	return nil
+}
+
+func (ks *KeySet) set(keys []JWTKey) {
+	m := make(map[string]*JWTKey, len(keys))
+	for i := range keys {
+		key := keys[i]
+		m[key.ID] = &key
+	}
+
+	ks.mx.Lock()
+	ks.keys = m
+	ks.mx.Unlock()
+}
+
+// LookupKey conforms to the KeyResolver interface.
+// Empty kid is accepted only if set contains a single key.
+func (ks *KeySet) LookupKey(kid string) (*JWTKey, error) {
+	ks.mx.RLock()
+	defer ks.mx.RUnlock()
+
+	if key, ok := ks.keys[kid]; ok {
+		return key, nil
+	}
+
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// symmetric
+	K string `json:"k"`
+}
+
+// ParseJWKS returns signature verification keys of the JWK set, ignoring encryption keys
+func ParseJWKS(data []byte) ([]JWTKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("httplib: unable to parse JWKS: %s", err)
+	}
+
+	keys := make([]JWTKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.parse()
+		if err != nil {
+			return nil, fmt.Errorf("httplib: invalid JWK %q: %s", k.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (k jwk) parse() (JWTKey, error) {
+	key := JWTKey{ID: k.Kid, Algorithm: k.Alg}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return key, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return key, err
+		}
+		key.Key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		key.Algorithm = valueOrDefault(key.Algorithm, AlgRS256)
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return key, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return key, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return key, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return key, fmt.Errorf("point is not on curve")
+		}
+		key.Key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		key.Algorithm = valueOrDefault(key.Algorithm, AlgES256)
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return key, err
+		}
+		if len(secret) == 0 {
+			return key, ErrEmptySigningKey
+		}
+		key.Key = secret
+		key.Algorithm = valueOrDefault(key.Algorithm, AlgHS256)
+
+	default:
+		return key, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+
+	return key, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
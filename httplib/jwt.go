@@ -0,0 +1,383 @@
+package httplib
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+var (
+	ErrInvalidToken        = fmt.Errorf("httplib: invalid token")
+	ErrTokenNotValidYet    = fmt.Errorf("httplib: token is not valid yet")
+	ErrInvalidIssuer       = fmt.Errorf("httplib: invalid token issuer")
+	ErrInvalidAudience     = fmt.Errorf("httplib: invalid token audience")
+	ErrUnsupportedAlg      = fmt.Errorf("httplib: unsupported token algorithm")
+	ErrUnknownKey          = fmt.Errorf("httplib: unknown token key")
+	ErrInvalidKeyAlgorithm = fmt.Errorf("httplib: key does not match token algorithm")
+)
+
+// Audience is the JWT "aud" claim, which is either a string or an array of strings
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+	return nil
+}
+
+// Contains reports whether audience contains specified value
+func (a Audience) Contains(v string) bool {
+	for _, aud := range a {
+		if aud == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Claims contains registered JWT claims, other claims are kept in Extra
+type Claims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+
+	Extra map[string]interface{} `json:"-"`
+}
+
+// registeredClaims is used to avoid MarshalJSON recursion
+type registeredClaims Claims
+
+var registeredClaimNames = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+func (c Claims) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(registeredClaims(c))
+	if err != nil || len(c.Extra) == 0 {
+		return data, err
+	}
+
+	all := make(map[string]interface{}, len(c.Extra)+len(registeredClaimNames))
+	for k, v := range c.Extra {
+		all[k] = v
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(all)
+}
+
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*registeredClaims)(c)); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&c.Extra); err != nil {
+		return err
+	}
+
+	for _, name := range registeredClaimNames {
+		delete(c.Extra, name)
+	}
+	if len(c.Extra) == 0 {
+		c.Extra = nil
+	}
+
+	return nil
+}
+
+// JWTKey is a key used to sign or verify tokens.
+// Key is []byte for HS256, *rsa.PrivateKey or *rsa.PublicKey for RS256
+// and *ecdsa.PrivateKey or *ecdsa.PublicKey for ES256.
+type JWTKey struct {
+	ID        string
+	Algorithm string
+	Key       interface{}
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// SignJWT returns compact serialized token signed by specified key
+func SignJWT(key JWTKey, claims Claims) (string, error) {
+	header, err := json.Marshal(jwtHeader{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+
+	sig, err := signJWS(key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+func signJWS(key JWTKey, input []byte) ([]byte, error) {
+	sum := sha256.Sum256(input)
+
+	switch key.Algorithm {
+	case AlgHS256:
+		secret, ok := key.Key.([]byte)
+		if !ok {
+			return nil, ErrInvalidKeyAlgorithm
+		}
+		if len(secret) == 0 {
+			return nil, ErrEmptySigningKey
+		}
+		return hmacSum(secret, input), nil
+
+	case AlgRS256:
+		priv, ok := key.Key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKeyAlgorithm
+		}
+		return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, sum[:])
+
+	case AlgES256:
+		priv, ok := key.Key.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve != elliptic.P256() {
+			return nil, ErrInvalidKeyAlgorithm
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, sum[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS uses fixed size R || S instead of ASN.1
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+
+	default:
+		return nil, ErrUnsupportedAlg
+	}
+}
+
+func verifyJWS(key *JWTKey, input, sig []byte) error {
+	sum := sha256.Sum256(input)
+
+	switch key.Algorithm {
+	case AlgHS256:
+		secret, ok := key.Key.([]byte)
+		if !ok {
+			return ErrInvalidKeyAlgorithm
+		}
+		if len(secret) == 0 {
+			return ErrEmptySigningKey
+		}
+		if !hmac.Equal(sig, hmacSum(secret, input)) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case AlgRS256:
+		var pub *rsa.PublicKey
+		switch k := key.Key.(type) {
+		case *rsa.PublicKey:
+			pub = k
+		case *rsa.PrivateKey:
+			pub = &k.PublicKey
+		default:
+			return ErrInvalidKeyAlgorithm
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case AlgES256:
+		var pub *ecdsa.PublicKey
+		switch k := key.Key.(type) {
+		case *ecdsa.PublicKey:
+			pub = k
+		case *ecdsa.PrivateKey:
+			pub = &k.PublicKey
+		default:
+			return ErrInvalidKeyAlgorithm
+		}
+		if len(sig) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, sum[:], r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	default:
+		return ErrUnsupportedAlg
+	}
+}
+
+// KeyResolver looks up token verification key by its ID
+type KeyResolver interface {
+	LookupKey(kid string) (*JWTKey, error)
+}
+
+// JWTVerifier verifies token signature and standard claims
+type JWTVerifier struct {
+	Keys KeyResolver
+	// Issuer is checked if not empty
+	Issuer string
+	// Audience is checked if not empty
+	Audience string
+	// Leeway is a clock skew tolerance applied to exp, nbf and iat claims
+	Leeway time.Duration
+	// Now returns current time, time.Now is used if nil
+	Now func() time.Time
+}
+
+// Verify returns claims of the valid token
+func (v *JWTVerifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	enc := base64.RawURLEncoding
+	headerData, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var header jwtHeader
+	if err := json.Unmarshal(headerData, &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := v.Keys.LookupKey(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// algorithm is defined by the key, so token can't downgrade it
+	if header.Algorithm != key.Algorithm {
+		return nil, ErrInvalidKeyAlgorithm
+	}
+
+	sig, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := verifyJWS(key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	payload, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims := new(Claims)
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *JWTVerifier) validate(c *Claims) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	leeway := int64(v.Leeway / time.Second)
+	unix := now.Unix()
+
+	if c.ExpiresAt > 0 && unix >= c.ExpiresAt+leeway {
+		return ErrTokenExpired
+	}
+	if c.NotBefore > 0 && unix < c.NotBefore-leeway {
+		return ErrTokenNotValidYet
+	}
+	if c.IssuedAt > 0 && unix < c.IssuedAt-leeway {
+		return ErrTokenNotValidYet
+	}
+	if v.Issuer != "" && c.Issuer != v.Issuer {
+		return ErrInvalidIssuer
+	}
+	if v.Audience != "" && !c.Audience.Contains(v.Audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+// Authenticate conforms to the Authenticator interface, so JWTVerifier could be used with AuthMiddleware
+func (v *JWTVerifier) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	claims, err := v.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Principal{Subject: claims.Subject, Claims: claims}, nil
+}
+
+// ClaimsFromContext returns JWT claims of the principal authenticated by JWTVerifier
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	claims, ok := p.Claims.(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,138 @@
+package httplib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+
+	enc := base64.RawURLEncoding
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "oct", "kid": "hmac", "k": %q},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"}
+	]}`,
+		enc.EncodeToString(rsaKey.N.Bytes()), enc.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		enc.EncodeToString(ecKey.X.Bytes()), enc.EncodeToString(ecKey.Y.Bytes()),
+		enc.EncodeToString(secret),
+	)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := ioutil.WriteFile(path, []byte(jwks), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadJWKSFile(path)
+	if err != nil {
+		t.Fatalf("LoadJWKSFile() error = %s", err)
+	}
+
+	now := time.Date(2022, 5, 19, 12, 0, 0, 0, time.UTC)
+	v := &JWTVerifier{
+		Keys:     keys,
+		Issuer:   "rovergulf",
+		Audience: "api",
+		Leeway:   30 * time.Second,
+		Now:      func() time.Time { return now },
+	}
+
+	claims := Claims{
+		Issuer:    "rovergulf",
+		Subject:   "user-1",
+		Audience:  Audience{"api", "web"},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute).Unix(),
+		Extra:     map[string]interface{}{"role": "admin"},
+	}
+
+	tests := []struct {
+		name   string
+		key    JWTKey
+		modify func(c *Claims)
+		err    error
+	}{
+		{name: "HS256", key: JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: secret}},
+		{name: "RS256", key: JWTKey{ID: "rsa", Algorithm: AlgRS256, Key: rsaKey}},
+		{name: "ES256", key: JWTKey{ID: "ec", Algorithm: AlgES256, Key: ecKey}},
+		{name: "expired within leeway", key: JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: secret}, modify: func(c *Claims) { c.ExpiresAt = now.Add(-10 * time.Second).Unix() }},
+		{name: "expired", key: JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: secret}, modify: func(c *Claims) { c.ExpiresAt = now.Add(-time.Minute).Unix() }, err: ErrTokenExpired},
+		{name: "not valid yet", key: JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: secret}, modify: func(c *Claims) { c.NotBefore = now.Add(time.Minute).Unix() }, err: ErrTokenNotValidYet},
+		{name: "wrong audience", key: JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: secret}, modify: func(c *Claims) { c.Audience = Audience{"web"} }, err: ErrInvalidAudience},
+		{name: "wrong issuer", key: JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: secret}, modify: func(c *Claims) { c.Issuer = "other" }, err: ErrInvalidIssuer},
+		{name: "unknown kid", key: JWTKey{ID: "other", Algorithm: AlgHS256, Key: secret}, err: ErrUnknownKey},
+		{name: "encryption key", key: JWTKey{ID: "enc", Algorithm: AlgHS256, Key: secret}, err: ErrUnknownKey},
+		{name: "wrong secret", key: JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: []byte("other")}, err: ErrInvalidSignature},
+		{name: "algorithm confusion", key: JWTKey{ID: "rsa", Algorithm: AlgHS256, Key: rsaKey.N.Bytes()}, err: ErrInvalidKeyAlgorithm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := claims
+			if tt.modify != nil {
+				tt.modify(&c)
+			}
+
+			token, err := SignJWT(tt.key, c)
+			if err != nil {
+				t.Fatalf("SignJWT() error = %s", err)
+			}
+
+			got, err := v.Verify(token)
+			if err != tt.err {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.Subject != c.Subject || !got.Audience.Contains("web") || fmt.Sprint(got.Extra["role"]) != "admin" {
+				t.Errorf("Verify() claims = %+v, want %+v", got, c)
+			}
+		})
+	}
+}
+
+func TestJWT_emptySecret(t *testing.T) {
+	if _, err := SignJWT(JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: []byte{}}, Claims{Subject: "admin"}); err != ErrEmptySigningKey {
+		t.Errorf("SignJWT() with empty secret error = %v, want %v", err, ErrEmptySigningKey)
+	}
+
+	// token signed with empty secret by someone else
+	enc := base64.RawURLEncoding
+	input := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT","kid":"hmac"}`)) + "." + enc.EncodeToString([]byte(`{"sub":"admin"}`))
+	token := input + "." + enc.EncodeToString(hmacSum(nil, []byte(input)))
+
+	v := &JWTVerifier{Keys: NewKeySet(JWTKey{ID: "hmac", Algorithm: AlgHS256, Key: []byte{}})}
+	if _, err := v.Verify(token); err != ErrEmptySigningKey {
+		t.Errorf("Verify() with empty secret error = %v, want %v", err, ErrEmptySigningKey)
+	}
+
+	for _, jwks := range []string{
+		`{"keys": [{"kty": "oct", "kid": "hmac"}]}`,
+		`{"keys": [{"kty": "oct", "kid": "hmac", "k": ""}]}`,
+	} {
+		if _, err := ParseJWKS([]byte(jwks)); err == nil {
+			t.Errorf("ParseJWKS(%s) expected error for empty key", jwks)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package httplib
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/rovergulf/utils/ipaddr"
+	"net/http"
+)
+
+// CORSMiddleware applies interceptor CORS policy and answers preflight requests
+func (i *Interceptor) CORSMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Set request headers for AJAX requests
+		if policy := i.corsPolicy(r); policy != nil && r.Header.Get(headerOrigin) != "" {
+			if isPreflightRequest(r) {
+				policy.handlePreflight(w, r)
+				return
+			}
+			policy.handleRequest(w, r)
+		}
+
+		// handle non-CORS OPTIONS request
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ContextMiddleware stores RequestInfo in the request context
+// and echoes request ID in the response header
+func (i *Interceptor) ContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := &RequestInfo{
+			ID:             requestID(r),
+			Host:           r.Host,
+			Path:           r.URL.Path,
+			RemoteAddr:     r.RemoteAddr,
+			XForwardedFor:  r.Header.Get(ipaddr.XForwardedFor),
+			CFConnectingIP: r.Header.Get(ipaddr.CFConnectingIp),
+			Route:          i.routeTemplate(r),
+		}
+
+		w.Header().Set(HeaderRequestID, info.ID)
+
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestInfo(r.Context(), info)))
+	})
+}
+
+// TracingMiddleware starts a server span for every request, if interceptor has a Tracer.
+// Span context passed by the caller in Jaeger, B3 or W3C headers becomes a parent of the span,
+// depending on propagators supported by the Tracer.
+func (i *Interceptor) TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i.Tracer == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var opts []opentracing.StartSpanOption
+		parent, err := i.Tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+		if err == nil {
+			opts = append(opts, ext.RPCServerOption(parent))
+		} else {
+			opts = append(opts, ext.SpanKindRPCServer)
+			if err != opentracing.ErrSpanContextNotFound && i.Logger != nil {
+				i.Logger.Debugf("Unable to extract span context: %s", err)
+			}
+		}
+
+		// route template is used to keep span names cardinality low
+		route := i.requestRoute(r)
+		name := r.Method
+		if route != "" {
+			name += " " + route
+		}
+
+		span := i.Tracer.StartSpan(name, opts...)
+		defer span.Finish()
+
+		ext.HTTPMethod.Set(span, r.Method)
+		ext.HTTPUrl.Set(span, r.URL.Path)
+		span.SetTag("http.route", route)
+		span.SetTag("host", r.Host)
+		span.SetTag("query", r.URL.RawQuery)
+		span.SetTag("remote_addr", r.RemoteAddr)
+		span.SetTag("x_forwarded_for", r.Header.Get(ipaddr.XForwardedFor))
+		if id := RequestIDFromContext(r.Context()); id != "" {
+			span.SetTag("request_id", id)
+		}
+
+		rw, ww := wrapResponseWriter(w)
+		next.ServeHTTP(ww, r.WithContext(opentracing.ContextWithSpan(r.Context(), span)))
+
+		status := rw.Status()
+		ext.HTTPStatusCode.Set(span, uint16(status))
+		if status >= http.StatusInternalServerError {
+			ext.Error.Set(span, true)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package httplib
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/rovergulf/utils/tracing"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// w3cExtractor adapts tracing.W3CPropagator to the mock tracer
+type w3cExtractor struct{}
+
+func (w3cExtractor) Extract(carrier interface{}) (mocktracer.MockSpanContext, error) {
+	sc, err := tracing.W3CPropagator{}.Extract(carrier)
+	if err != nil {
+		return mocktracer.MockSpanContext{}, err
+	}
+	return mocktracer.MockSpanContext{
+		TraceID: int(sc.TraceID().Low),
+		SpanID:  int(sc.SpanID()),
+		Sampled: sc.IsSampled(),
+	}, nil
+}
+
+func TestInterceptor_TracingMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if opentracing.SpanFromContext(r.Context()) == nil {
+			t.Error("span is not passed in request context")
+		}
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+
+	tracer := mocktracer.New()
+	tracer.RegisterExtractor(opentracing.HTTPHeaders, w3cExtractor{})
+
+	i := NewInterceptor(zap.NewNop().Sugar(), nil, nil)
+	i.Router = router
+	i.Tracer = tracer
+
+	tests := []struct {
+		name        string
+		target      string
+		traceParent string
+		traceID     int
+		parentID    int
+		status      uint16
+		error       bool
+	}{
+		{"child of caller span", "/users/42?fail=1", "00-0000000000000000000000000000002a-000000000000002b-01", 0x2a, 0x2b, http.StatusServiceUnavailable, true},
+		{"root span", "/users/43", "", 0, 0, http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer.Reset()
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.traceParent != "" {
+				r.Header.Set(tracing.TraceParentHeader, tt.traceParent)
+			}
+			i.ServeHTTP(httptest.NewRecorder(), r)
+
+			spans := tracer.FinishedSpans()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 finished span, got %d", len(spans))
+			}
+			span := spans[0]
+
+			if span.OperationName != "GET /users/{id}" {
+				t.Errorf("operation name = %q, want %q", span.OperationName, "GET /users/{id}")
+			}
+			if span.ParentID != tt.parentID {
+				t.Errorf("parent ID = %x, want %x", span.ParentID, tt.parentID)
+			}
+			if tt.traceID != 0 && span.SpanContext.TraceID != tt.traceID {
+				t.Errorf("trace ID = %x, want %x", span.SpanContext.TraceID, tt.traceID)
+			}
+			if got := span.Tag("span.kind"); got != ext.SpanKindRPCServerEnum {
+				t.Errorf("span.kind = %v, want %v", got, ext.SpanKindRPCServerEnum)
+			}
+			if got := span.Tag("http.route"); got != "/users/{id}" {
+				t.Errorf("http.route = %v, want /users/{id}", got)
+			}
+			if got := span.Tag("http.status_code"); got != tt.status {
+				t.Errorf("http.status_code = %v, want %d", got, tt.status)
+			}
+			if got, _ := span.Tag("error").(bool); got != tt.error {
+				t.Errorf("error = %v, want %v", got, tt.error)
+			}
+		})
+	}
+}
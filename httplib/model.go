@@ -1,41 +1,15 @@
 package httplib
 
 import (
-	"fmt"
+	"github.com/rovergulf/utils/response"
 	"time"
 )
 
-type ApiError struct {
-	HttpStatus int         `json:"http_status,omitempty"`
-	ErrorCode  int         `json:"code"`
-	Message    interface{} `json:"message"`
-	Timestamp  interface{} `json:"timestamp"`
-}
-
-func (e ApiError) Error() string {
-	return e.String()
-}
-
-func (e ApiError) String() string {
-	msg := fmt.Sprintf("%s", e.Message)
-
-	if e.HttpStatus > 0 {
-		msg = fmt.Sprintf("[HTTP_CODE:%d] %s", e.HttpStatus, msg)
-	}
-
-	if e.ErrorCode > 0 {
-		msg = fmt.Sprintf("[INTERNAL_CODE:%d] %s", e.ErrorCode, msg)
-	}
-
-	return fmt.Sprintf("%s", msg)
-}
+// ApiError is an error response rendered as RFC 7807 problem details, see response.ApiError
+type ApiError = response.ApiError
 
 func NewApiError(code int, msg string) *ApiError {
-	return &ApiError{
-		ErrorCode: code,
-		Message:   msg,
-		Timestamp: time.Now().Format(time.RFC1123),
-	}
+	return response.NewApiError(code, msg)
 }
 
 type CreatedObjectId struct {
@@ -46,10 +20,19 @@ type CreatedObjectId struct {
 type ListResult struct {
 	Results interface{} `json:"results,omitempty" yaml:"results,omitempty"`
 	Count   int32       `json:"count" yaml:"count"`
+	Total   *int64      `json:"total,omitempty" yaml:"total,omitempty"`
 	HasPrev bool        `json:"has_prev" yaml:"has_prev"`
 	HasNext bool        `json:"has_next" yaml:"has_next"`
 }
 
+// CursorListResult is a keyset paginated counterpart of ListResult
+type CursorListResult struct {
+	Results    interface{} `json:"results,omitempty" yaml:"results,omitempty"`
+	Count      int32       `json:"count" yaml:"count"`
+	NextCursor string      `json:"next_cursor,omitempty" yaml:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty" yaml:"prev_cursor,omitempty"`
+}
+
 type Response struct {
 	Success   bool        `json:"success" yaml:"success"`
 	Timestamp interface{} `json:"timestamp,omitempty" yaml:"timestamp,omitempty" `
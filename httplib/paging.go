@@ -1,44 +1,202 @@
 package httplib
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
-func GetLimitAndOffsetFromRequest(r *http.Request) (int, int) {
-	page, _ := strconv.Atoi(r.FormValue("page"))
-	offset, _ := strconv.Atoi(r.FormValue("offset"))
-	limit, _ := strconv.Atoi(r.FormValue("limit"))
+// defaultPageLimit is used if Paginator has no DefaultLimit set
+const defaultPageLimit = 10
+
+// DefaultPaginator is used by package level paging functions
+var DefaultPaginator = Paginator{
+	DefaultLimit: 10,
+	MaxLimit:     100,
+}
+
+// Paginator parses page/offset based paging request parameters
+type Paginator struct {
+	// DefaultLimit is used if request has no limit, 10 if it is not set
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Page is a requested page of the list
+type Page struct {
+	Number int
+	Limit  int
+	Offset int
+}
+
+// PagingError describes invalid paging request parameter
+type PagingError struct {
+	Param string
+	Value string
+}
+
+func (e PagingError) Error() string {
+	return fmt.Sprintf("invalid %s value %q: must be a non-negative integer", e.Param, e.Value)
+}
+
+// Parse returns page requested by "page", "offset" and "limit" parameters,
+// or PagingError if any of them is not a non-negative integer.
+// Limit exceeding MaxLimit is reduced to MaxLimit.
+func (p Paginator) Parse(r *http.Request) (Page, error) {
+	page, err := formInt(r, "page")
+	if err != nil {
+		return Page{}, err
+	}
+
+	offset, err := formInt(r, "offset")
+	if err != nil {
+		return Page{}, err
+	}
+
+	limit, err := formInt(r, "limit")
+	if err != nil {
+		return Page{}, err
+	}
 
 	if offset > 0 && page == 0 {
-		page = offset / limit
+		limit = p.limit(limit)
+		return Page{Number: offset/limit + 1, Limit: limit, Offset: offset}, nil
 	}
 
-	return GetLimitAndOffsetFromPageNumber(page, limit)
+	return p.FromPageNumber(page, limit), nil
 }
 
-func GetPagingInt32FromRequest(r *http.Request) (int32, int32) {
-	page, _ := strconv.Atoi(r.FormValue("page"))
-	offset, _ := strconv.Atoi(r.FormValue("offset"))
-	limit, _ := strconv.Atoi(r.FormValue("limit"))
+// FromPageNumber returns page with normalized number and limit
+func (p Paginator) FromPageNumber(page, limit int) Page {
+	if page < 1 {
+		page = 1
+	}
 
-	if offset > 0 && page == 0 {
-		page = offset / limit
+	limit = p.limit(limit)
+
+	return Page{
+		Number: page,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+}
+
+func (p Paginator) limit(limit int) int {
+	if limit <= 0 {
+		limit = p.DefaultLimit
+	}
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if p.MaxLimit > 0 && limit > p.MaxLimit {
+		limit = p.MaxLimit
+	}
+	return limit
+}
+
+func formInt(r *http.Request, key string) (int, error) {
+	v := r.FormValue(key)
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, PagingError{Param: key, Value: v}
+	}
+
+	return n, nil
+}
+
+// HasPrev reports whether there is a page before this one
+func (p Page) HasPrev() bool {
+	return p.Offset > 0
+}
+
+// HasNext reports whether there are rows after this page
+func (p Page) HasNext(total int64) bool {
+	return int64(p.Offset+p.Limit) < total
+}
+
+// ListResult returns ListResult for the page with total count of rows
+func (p Page) ListResult(results interface{}, count int32, total int64) ListResult {
+	return ListResult{
+		Results: results,
+		Count:   count,
+		Total:   &total,
+		HasPrev: p.HasPrev(),
+		HasNext: p.HasNext(total),
+	}
+}
+
+// SetLinkHeader sets RFC 8288 Link header with first, prev, next and last page links,
+// built from the current request URL
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, page Page, total int64) {
+	var links []string
+	link := func(rel string, number int) {
+		u := *r.URL
+		q := u.Query()
+		q.Del("offset")
+		q.Set("page", strconv.Itoa(number))
+		q.Set("limit", strconv.Itoa(page.Limit))
+		u.RawQuery = q.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, requestURI(&u), rel))
+	}
+
+	last := 1
+	if page.Limit > 0 && total > 0 {
+		last = int((total + int64(page.Limit) - 1) / int64(page.Limit))
+	}
+
+	link("first", 1)
+	if page.HasPrev() {
+		prev := page.Number - 1
+		if prev > last {
+			prev = last
+		}
+		link("prev", prev)
+	}
+	if page.HasNext(total) {
+		link("next", page.Number+1)
+	}
+	link("last", last)
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// requestURI returns path and query of the URL,
+// preserving scheme and host only if request URL is absolute
+func requestURI(u *url.URL) string {
+	if u.IsAbs() {
+		return u.String()
 	}
+	return u.RequestURI()
+}
+
+func GetLimitAndOffsetFromRequest(r *http.Request) (int, int) {
+	return GetLimitAndOffsetFromPageNumber(getPageAndLimitFromRequest(r))
+}
 
-	return GetPagingInt32FromPageNumber(page, limit)
+func GetPagingInt32FromRequest(r *http.Request) (int32, int32) {
+	return GetPagingInt32FromPageNumber(getPageAndLimitFromRequest(r))
 }
 
 func GetPagingInt64FromRequest(r *http.Request) (int64, int64) {
+	return GetPagingInt64FromPageNumber(getPageAndLimitFromRequest(r))
+}
+
+func getPageAndLimitFromRequest(r *http.Request) (int, int) {
 	page, _ := strconv.Atoi(r.FormValue("page"))
 	offset, _ := strconv.Atoi(r.FormValue("offset"))
 	limit, _ := strconv.Atoi(r.FormValue("limit"))
 
 	if offset > 0 && page == 0 {
-		page = offset / limit
+		page = offset/DefaultPaginator.limit(limit) + 1
 	}
 
-	return GetPagingInt64FromPageNumber(page, limit)
+	return page, limit
 }
 
 func GetPagingInt32FromPageNumber(page, limit int) (int32, int32) {
@@ -52,21 +210,6 @@ func GetPagingInt64FromPageNumber(page, limit int) (int64, int64) {
 }
 
 func GetLimitAndOffsetFromPageNumber(page, limit int) (int, int) {
-	if page == 0 {
-		page = 1
-	}
-	if page < 1 {
-		page = 1
-	}
-
-	if limit == 0 {
-		limit = 10
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset := (page - 1) * limit
-
-	return limit, offset
+	p := DefaultPaginator.FromPageNumber(page, limit)
+	return p.Limit, p.Offset
 }
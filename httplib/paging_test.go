@@ -0,0 +1,92 @@
+package httplib
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetLimitAndOffsetFromRequest(t *testing.T) {
+	tests := []struct {
+		query  string
+		limit  int
+		offset int
+	}{
+		{"", 10, 0},
+		{"page=3&limit=20", 20, 40},
+		{"offset=20", 10, 20},
+		{"offset=40&limit=20", 20, 40},
+		{"limit=1000", 100, 0},
+	}
+
+	for _, tt := range tests {
+		limit, offset := GetLimitAndOffsetFromRequest(httptest.NewRequest("GET", "/?"+tt.query, nil))
+		if limit != tt.limit || offset != tt.offset {
+			t.Errorf("GetLimitAndOffsetFromRequest(%q) = %d, %d, want %d, %d", tt.query, limit, offset, tt.limit, tt.offset)
+		}
+	}
+}
+
+func TestPaginator_Parse(t *testing.T) {
+	p := Paginator{DefaultLimit: 20, MaxLimit: 50}
+
+	tests := []struct {
+		query string
+		page  Page
+		err   bool
+	}{
+		{"", Page{Number: 1, Limit: 20, Offset: 0}, false},
+		{"page=2&limit=100", Page{Number: 2, Limit: 50, Offset: 50}, false},
+		{"offset=30&limit=10", Page{Number: 4, Limit: 10, Offset: 30}, false},
+		{"page=-1", Page{}, true},
+		{"limit=ten", Page{}, true},
+	}
+
+	for _, tt := range tests {
+		page, err := p.Parse(httptest.NewRequest("GET", "/?"+tt.query, nil))
+		if (err != nil) != tt.err {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.query, err, tt.err)
+			continue
+		}
+		if page != tt.page {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.query, page, tt.page)
+		}
+	}
+}
+
+func TestPaginator_ParseZeroDefaultLimit(t *testing.T) {
+	tests := []struct {
+		paginator Paginator
+		query     string
+		page      Page
+	}{
+		{Paginator{MaxLimit: 50}, "offset=30", Page{Number: 4, Limit: 10, Offset: 30}},
+		{Paginator{MaxLimit: 5}, "offset=30", Page{Number: 7, Limit: 5, Offset: 30}},
+		{Paginator{}, "page=2", Page{Number: 2, Limit: 10, Offset: 10}},
+	}
+
+	for _, tt := range tests {
+		page, err := tt.paginator.Parse(httptest.NewRequest("GET", "/?"+tt.query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page != tt.page {
+			t.Errorf("%+v.Parse(%q) = %+v, want %+v", tt.paginator, tt.query, page, tt.page)
+		}
+	}
+}
+
+func TestSetLinkHeader(t *testing.T) {
+	r := httptest.NewRequest("GET", "/items?sort=name&page=2&limit=10", nil)
+	w := httptest.NewRecorder()
+
+	page, _ := DefaultPaginator.Parse(r)
+	SetLinkHeader(w, r, page, 35)
+
+	want := `</items?limit=10&page=1&sort=name>; rel="first", ` +
+		`</items?limit=10&page=1&sort=name>; rel="prev", ` +
+		`</items?limit=10&page=3&sort=name>; rel="next", ` +
+		`</items?limit=10&page=4&sort=name>; rel="last"`
+	if got := w.Header().Get("Link"); got != want {
+		t.Errorf("Link = %s, want %s", got, want)
+	}
+}
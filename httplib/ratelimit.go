@@ -0,0 +1,298 @@
+package httplib
+
+import (
+	"context"
+	"fmt"
+	"github.com/rovergulf/utils/ipaddr"
+	"github.com/rovergulf/utils/response"
+	"go.uber.org/zap"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRateLimitPolicy    = "RateLimit-Policy"
+	headerRetryAfter         = "Retry-After"
+)
+
+var ErrRateLimited = fmt.Errorf("rate limit exceeded")
+
+// Limit is a number of requests allowed per period
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	// Burst is a token bucket capacity, Requests is used if it is zero
+	Burst int
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// RateLimitState is a limiter state of a single key, stored in RateLimitStore
+type RateLimitState struct {
+	// Tokens and Last are used by TokenBucket
+	Tokens float64   `json:"tokens,omitempty"`
+	Last   time.Time `json:"last,omitempty"`
+	// WindowStart, Count and PrevCount are used by SlidingWindow
+	WindowStart time.Time `json:"window_start,omitempty"`
+	Count       int       `json:"count,omitempty"`
+	PrevCount   int       `json:"prev_count,omitempty"`
+}
+
+// RateLimitResult describes limiter decision
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is a time until quota is fully restored
+	Reset time.Duration
+	// RetryAfter is a time until next request is allowed, zero if it is allowed
+	RetryAfter time.Duration
+}
+
+// RateLimitAlgorithm takes a request from the key quota
+type RateLimitAlgorithm interface {
+	Take(state *RateLimitState, now time.Time) RateLimitResult
+	// TTL is how long key state is relevant after last request
+	TTL() time.Duration
+	// Policy returns RateLimit-Policy header value
+	Policy() string
+}
+
+// TokenBucket refills Requests tokens per Period up to Burst, each request takes one token
+type TokenBucket struct {
+	Limit
+}
+
+func (tb TokenBucket) rate() float64 {
+	return float64(tb.Requests) / tb.Period.Seconds()
+}
+
+func (tb TokenBucket) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	capacity := float64(tb.burst())
+	rate := tb.rate()
+
+	if state.Last.IsZero() {
+		state.Tokens = capacity
+	} else if elapsed := now.Sub(state.Last).Seconds(); elapsed > 0 {
+		state.Tokens = math.Min(capacity, state.Tokens+elapsed*rate)
+	}
+	state.Last = now
+
+	res := RateLimitResult{Limit: tb.burst()}
+	if state.Tokens >= 1 {
+		state.Tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsDuration((1 - state.Tokens) / rate)
+	}
+
+	res.Remaining = int(state.Tokens)
+	res.Reset = secondsDuration((capacity - state.Tokens) / rate)
+	return res
+}
+
+func (tb TokenBucket) TTL() time.Duration {
+	return secondsDuration(float64(tb.burst()) / tb.rate())
+}
+
+func (tb TokenBucket) Policy() string {
+	return fmt.Sprintf("%d;w=%d;burst=%d", tb.Requests, int(tb.Period/time.Second), tb.burst())
+}
+
+// SlidingWindow allows Requests per Period, approximating sliding window
+// by weighted counters of current and previous fixed windows
+type SlidingWindow struct {
+	Limit
+}
+
+func (sw SlidingWindow) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	window := now.Truncate(sw.Period)
+	if !state.WindowStart.Equal(window) {
+		if state.WindowStart.Add(sw.Period).Equal(window) {
+			state.PrevCount = state.Count
+		} else {
+			state.PrevCount = 0
+		}
+		state.Count = 0
+		state.WindowStart = window
+	}
+
+	elapsed := now.Sub(window)
+	prevWeight := 1 - float64(elapsed)/float64(sw.Period)
+	weighted := float64(state.PrevCount)*prevWeight + float64(state.Count)
+
+	res := RateLimitResult{Limit: sw.Requests, Reset: sw.Period - elapsed}
+	if weighted+1 <= float64(sw.Requests) {
+		state.Count++
+		weighted++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = sw.retryAfter(state, elapsed)
+	}
+
+	res.Remaining = sw.Requests - int(math.Ceil(weighted))
+	if res.Remaining < 0 {
+		res.Remaining = 0
+	}
+	return res
+}
+
+// retryAfter returns time until previous window weight decreases enough to allow a request
+func (sw SlidingWindow) retryAfter(state *RateLimitState, elapsed time.Duration) time.Duration {
+	free := float64(sw.Requests - state.Count - 1)
+	if free < 0 || state.PrevCount == 0 {
+		return sw.Period - elapsed
+	}
+
+	at := time.Duration(float64(sw.Period) * (1 - free/float64(state.PrevCount)))
+	if at <= elapsed {
+		return time.Millisecond
+	}
+	return at - elapsed
+}
+
+func (sw SlidingWindow) TTL() time.Duration {
+	return 2 * sw.Period
+}
+
+func (sw SlidingWindow) Policy() string {
+	return fmt.Sprintf("%d;w=%d", sw.Requests, int(sw.Period/time.Second))
+}
+
+// RateLimitStore keeps limiters state, stores backed by shared storage
+// apply limits across every service instance
+type RateLimitStore interface {
+	// Update atomically applies fn to the state of key, state of unknown or expired key is zero.
+	// Updated state is kept for ttl.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state *RateLimitState)) error
+}
+
+// RateLimiter applies algorithm to requests identified by key
+type RateLimiter struct {
+	Algorithm RateLimitAlgorithm
+	Store     RateLimitStore
+	Now       func() time.Time
+}
+
+// NewRateLimiter returns limiter keeping state in memory
+func NewRateLimiter(algorithm RateLimitAlgorithm) *RateLimiter {
+	return &RateLimiter{
+		Algorithm: algorithm,
+		Store:     NewMemoryRateLimitStore(defaultRateLimitShards),
+	}
+}
+
+// Allow takes a request from key quota
+func (l *RateLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	now := time.Now()
+	if l.Now != nil {
+		now = l.Now()
+	}
+
+	var res RateLimitResult
+	err := l.Store.Update(ctx, key, l.Algorithm.TTL(), func(state *RateLimitState) {
+		res = l.Algorithm.Take(state, now)
+	})
+
+	return res, err
+}
+
+// RateLimitKeyByIP returns key func identifying requests by client IP address without port,
+// resolved by resolver. Headers are ignored if resolver is nil, so that clients
+// are unable to get a new quota by spoofing them.
+func RateLimitKeyByIP(resolver *ipaddr.Resolver) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		var ip net.IP
+		if resolver != nil {
+			ip, _ = resolver.Resolve(r)
+		} else {
+			ip = ipaddr.ParseIP(r.RemoteAddr)
+		}
+
+		if ip == nil {
+			return "ip:" + r.RemoteAddr
+		}
+		return "ip:" + ip.String()
+	}
+}
+
+// RateLimitKeyByPrincipal returns key func identifying requests by authenticated principal,
+// falling back to client IP address resolved by resolver for anonymous requests
+func RateLimitKeyByPrincipal(resolver *ipaddr.Resolver) func(r *http.Request) string {
+	byIP := RateLimitKeyByIP(resolver)
+	return func(r *http.Request) string {
+		if p, ok := PrincipalFromContext(r.Context()); ok && p.Subject != "" {
+			return "sub:" + p.Subject
+		}
+		return byIP(r)
+	}
+}
+
+// RateLimitConfig configures RateLimitMiddleware
+type RateLimitConfig struct {
+	Limiter *RateLimiter
+	// KeyFunc identifies request, RateLimitKeyByPrincipal with Resolver is used if it is nil
+	KeyFunc func(r *http.Request) string
+	// Resolver resolves client address of anonymous requests if KeyFunc is nil,
+	// connection address is used if it is nil
+	Resolver *ipaddr.Resolver
+	// Logger reports store errors, requests are allowed if store fails
+	Logger *zap.SugaredLogger
+}
+
+// RateLimitMiddleware rejects requests exceeding the limit with 429 ApiError.
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers
+// are set on every response, and Retry-After on rejected ones.
+func RateLimitMiddleware(conf RateLimitConfig) Middleware {
+	keyFunc := conf.KeyFunc
+	if keyFunc == nil {
+		keyFunc = RateLimitKeyByPrincipal(conf.Resolver)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := conf.Limiter.Allow(r.Context(), keyFunc(r))
+			if err != nil {
+				if conf.Logger != nil {
+					conf.Logger.Errorw("Rate limit store failed", "request_id", RequestIDFromContext(r.Context()), "err", err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set(headerRateLimitLimit, strconv.Itoa(res.Limit))
+			h.Set(headerRateLimitRemaining, strconv.Itoa(res.Remaining))
+			h.Set(headerRateLimitReset, strconv.Itoa(ceilSeconds(res.Reset)))
+			h.Set(headerRateLimitPolicy, conf.Limiter.Algorithm.Policy())
+
+			if !res.Allowed {
+				h.Set(headerRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				RequestErrorResponseJSON(w, r, http.StatusTooManyRequests, response.ErrCodeTooManyRequests, ErrRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ceilSeconds rounds duration up to whole seconds, as rate limit headers require
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,97 @@
+package httplib
+
+import (
+	"context"
+	"github.com/rovergulf/utils/concurrent"
+	"hash/fnv"
+	"time"
+)
+
+const (
+	defaultRateLimitShards = 64
+	// rateLimitSweepEvery is a number of shard updates between expired keys sweeps
+	rateLimitSweepEvery = 1024
+)
+
+type rateLimitEntry struct {
+	state   RateLimitState
+	expires time.Time
+}
+
+type rateLimitShard struct {
+	entries map[string]*rateLimitEntry
+	updates int
+}
+
+// MemoryRateLimitStore keeps limiters state in memory, split into shards
+// guarded by separate locks to reduce contention. Expired keys are swept periodically.
+type MemoryRateLimitStore struct {
+	locks  *concurrent.RWLockArray
+	shards []*rateLimitShard
+	now    func() time.Time
+}
+
+func NewMemoryRateLimitStore(shards int) *MemoryRateLimitStore {
+	if shards <= 0 {
+		shards = defaultRateLimitShards
+	}
+
+	s := &MemoryRateLimitStore{
+		locks:  concurrent.NewRWLockArray(shards),
+		shards: make([]*rateLimitShard, shards),
+		now:    time.Now,
+	}
+	for i := range s.shards {
+		s.shards[i] = &rateLimitShard{entries: make(map[string]*rateLimitEntry)}
+	}
+
+	return s
+}
+
+func (s *MemoryRateLimitStore) shardIndex(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(s.shards)))
+}
+
+func (s *MemoryRateLimitStore) Update(_ context.Context, key string, ttl time.Duration, fn func(state *RateLimitState)) error {
+	idx := s.shardIndex(key)
+	mx := s.locks.Get(idx)
+	mx.Lock()
+	defer mx.Unlock()
+
+	now := s.now()
+	shard := s.shards[idx]
+
+	entry, ok := shard.entries[key]
+	if !ok || now.After(entry.expires) {
+		entry = new(rateLimitEntry)
+		shard.entries[key] = entry
+	}
+
+	fn(&entry.state)
+	entry.expires = now.Add(ttl)
+
+	if shard.updates++; shard.updates >= rateLimitSweepEvery {
+		shard.updates = 0
+		for k, e := range shard.entries {
+			if now.After(e.expires) {
+				delete(shard.entries, k)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Len returns number of stored keys, including expired ones not swept yet
+func (s *MemoryRateLimitStore) Len() int {
+	s.locks.RLockAll()
+	defer s.locks.RUnlockAll()
+
+	var n int
+	for _, shard := range s.shards {
+		n += len(shard.entries)
+	}
+	return n
+}
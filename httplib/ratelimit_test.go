@@ -0,0 +1,167 @@
+package httplib
+
+import (
+	"context"
+	"github.com/rovergulf/utils/ipaddr"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Take(t *testing.T) {
+	tb := TokenBucket{Limit{Requests: 2, Period: time.Second, Burst: 3}}
+	now := time.Date(2022, 5, 19, 0, 0, 0, 0, time.UTC)
+	var state RateLimitState
+
+	for i := 0; i < 3; i++ {
+		if res := tb.Take(&state, now); !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i, res, 2-i)
+		}
+	}
+
+	res := tb.Take(&state, now)
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("burst exceeded: %+v, want rejected with 500ms retry", res)
+	}
+
+	if res := tb.Take(&state, now.Add(500*time.Millisecond)); !res.Allowed {
+		t.Errorf("refilled token is not allowed: %+v", res)
+	}
+}
+
+func TestSlidingWindow_Take(t *testing.T) {
+	sw := SlidingWindow{Limit{Requests: 10, Period: time.Minute}}
+	start := time.Date(2022, 5, 19, 0, 0, 0, 0, time.UTC)
+	var state RateLimitState
+
+	for i := 0; i < 10; i++ {
+		if res := sw.Take(&state, start.Add(time.Duration(i)*time.Second)); !res.Allowed {
+			t.Fatalf("request %d is rejected: %+v", i, res)
+		}
+	}
+	if res := sw.Take(&state, start.Add(30*time.Second)); res.Allowed {
+		t.Fatalf("request over limit is allowed: %+v", res)
+	}
+
+	// a quarter into the next window previous one still weights 7.5 requests
+	next := start.Add(75 * time.Second)
+	for i := 0; i < 2; i++ {
+		if res := sw.Take(&state, next); !res.Allowed {
+			t.Fatalf("request %d in next window is rejected: %+v", i, res)
+		}
+	}
+
+	res := sw.Take(&state, next)
+	if res.Allowed {
+		t.Fatalf("request over weighted limit is allowed: %+v", res)
+	}
+	if res.RetryAfter != 3*time.Second {
+		t.Errorf("RetryAfter = %s, want 3s", res.RetryAfter)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(TokenBucket{Limit{Requests: 1, Period: time.Minute}})
+	handler := RateLimitMiddleware(RateLimitConfig{Limiter: limiter})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(ip, subject string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = ip + ":1234"
+		if subject != "" {
+			r = r.WithContext(ContextWithPrincipal(r.Context(), &Principal{Subject: subject}))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := request("10.0.0.1", ""); w.Code != http.StatusOK || w.Header().Get(headerRateLimitRemaining) != "0" {
+		t.Fatalf("first request: status %d, headers %v", w.Code, w.Header())
+	}
+
+	w := request("10.0.0.1", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get(headerRetryAfter); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+
+	if w := request("10.0.0.1", "user"); w.Code != http.StatusOK {
+		t.Errorf("principal is limited by IP quota, status %d", w.Code)
+	}
+	if w := request("10.0.0.2", ""); w.Code != http.StatusOK {
+		t.Errorf("another IP is limited, status %d", w.Code)
+	}
+}
+
+func TestRateLimitKeyByIP(t *testing.T) {
+	resolver, err := ipaddr.NewResolver("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		resolver   *ipaddr.Resolver
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"port is ignored", nil, "203.0.113.5:5555", nil, "ip:203.0.113.5"},
+		{"ipv6 port is ignored", nil, "[2001:db8::1]:443", nil, "ip:2001:db8::1"},
+		{"headers ignored without resolver", nil, "203.0.113.5:5556", map[string]string{ipaddr.XForwardedFor: "1.1.1.1", ipaddr.CFConnectingIp: "1.1.1.2"}, "ip:203.0.113.5"},
+		{"spoofed by direct client", resolver, "203.0.113.5:5557", map[string]string{ipaddr.XForwardedFor: "1.1.1.1", ipaddr.CFConnectingIp: "1.1.1.2"}, "ip:203.0.113.5"},
+		{"trusted proxy", resolver, "10.0.0.1:80", map[string]string{ipaddr.XForwardedFor: "198.51.100.4"}, "ip:198.51.100.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			if got := RateLimitKeyByIP(tt.resolver)(r); got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware_rotation(t *testing.T) {
+	limiter := NewRateLimiter(TokenBucket{Limit{Requests: 1, Period: time.Minute}})
+	handler := RateLimitMiddleware(RateLimitConfig{Limiter: limiter})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i, port := range []string{"1111", "2222"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "203.0.113.5:" + port
+		r.Header.Set(ipaddr.XForwardedFor, "198.51.100."+port[:1])
+		r.Header.Set(ipaddr.CFConnectingIp, "192.0.2."+port[:1])
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if i > 0 && w.Code != http.StatusTooManyRequests {
+			t.Errorf("new connection with spoofed headers got fresh quota, status %d", w.Code)
+		}
+	}
+}
+
+func TestMemoryRateLimitStore_expiration(t *testing.T) {
+	s := NewMemoryRateLimitStore(4)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	inc := func(state *RateLimitState) { state.Count++ }
+	s.Update(context.Background(), "key", time.Second, inc)
+	s.Update(context.Background(), "key", time.Second, inc)
+
+	now = now.Add(2 * time.Second)
+	var count int
+	s.Update(context.Background(), "key", time.Second, func(state *RateLimitState) { count = state.Count })
+	if count != 0 {
+		t.Errorf("expired state count = %d, want 0", count)
+	}
+}
@@ -0,0 +1,70 @@
+package httplib
+
+import (
+	"fmt"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/rovergulf/utils/response"
+	"net/http"
+	"runtime/debug"
+)
+
+// RecoveryMiddleware recovers from panics in handlers, logs the stack
+// and responds with 500 ApiError carrying the request ID.
+// Panic value and stack are included in response only if Interceptor is in Development mode.
+func (i *Interceptor) RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw, ww := wrapResponseWriter(w)
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			// the way to abort the response deliberately, net/http handles it silently
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			stack := string(debug.Stack())
+			requestId := RequestIDFromContext(r.Context())
+
+			if i.Logger != nil {
+				i.Logger.Errorw("Recovered from panic",
+					"request_id", requestId,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"panic", rec,
+					"stack", stack,
+				)
+			}
+
+			if span := opentracing.SpanFromContext(r.Context()); span != nil {
+				ext.Error.Set(span, true)
+				span.LogFields(
+					log.String("event", "panic"),
+					log.String("message", fmt.Sprint(rec)),
+					log.String("stack", stack),
+				)
+			}
+
+			// nothing could be done if handler has already started writing response
+			if rw.Written() {
+				return
+			}
+
+			apiErr := response.NewProblem(http.StatusInternalServerError, 0, "")
+			apiErr.RequestId = requestId
+			if i.Development {
+				apiErr.Detail = fmt.Sprint(rec)
+				apiErr.Stack = stack
+			}
+
+			writeProblem(rw, r, apiErr)
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
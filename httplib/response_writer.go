@@ -0,0 +1,171 @@
+package httplib
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// responseWriter captures response status code and size
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+// recorder is implemented by writers returned by wrapResponseWriter
+type recorder interface {
+	recorder() *responseWriter
+}
+
+// wrapResponseWriter returns recorder of w and writer to be passed to the next handler.
+// The writer implements only those of http.Flusher, http.Hijacker and http.Pusher, which w does,
+// so handlers are able to detect supported features by type assertion.
+// Writer already wrapped by middleware chain is reused.
+func wrapResponseWriter(w http.ResponseWriter) (*responseWriter, http.ResponseWriter) {
+	if r, ok := w.(recorder); ok {
+		return r.recorder(), w
+	}
+
+	rw := &responseWriter{ResponseWriter: w}
+	return rw, rw.expose()
+}
+
+// expose returns w extended with optional interfaces of the wrapped writer
+func (w *responseWriter) expose() http.ResponseWriter {
+	_, canFlush := w.ResponseWriter.(http.Flusher)
+	_, canHijack := w.ResponseWriter.(http.Hijacker)
+	_, canPush := w.ResponseWriter.(http.Pusher)
+	f, h, p := rwFlusher{w}, rwHijacker{w}, rwPusher{w}
+
+	switch {
+	case canFlush && canHijack && canPush:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, f, h, p}
+	case canFlush && canHijack:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case canFlush && canPush:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Pusher
+		}{w, f, p}
+	case canHijack && canPush:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			http.Pusher
+		}{w, h, p}
+	case canFlush:
+		return struct {
+			*responseWriter
+			http.Flusher
+		}{w, f}
+	case canHijack:
+		return struct {
+			*responseWriter
+			http.Hijacker
+		}{w, h}
+	case canPush:
+		return struct {
+			*responseWriter
+			http.Pusher
+		}{w, p}
+	default:
+		return w
+	}
+}
+
+func (w *responseWriter) recorder() *responseWriter {
+	return w
+}
+
+// Status returns written status code, 200 if handler has not called WriteHeader explicitly
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns number of written body bytes
+func (w *responseWriter) Size() int64 {
+	return w.size
+}
+
+// Written reports whether response headers were already sent
+func (w *responseWriter) Written() bool {
+	return w.wroteHeader
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.status = code
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+type rwFlusher struct{ w *responseWriter }
+
+func (f rwFlusher) Flush() {
+	if !f.w.wroteHeader {
+		f.w.WriteHeader(http.StatusOK)
+	}
+	f.w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type rwHijacker struct{ w *responseWriter }
+
+func (h rwHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.w.hijack()
+}
+
+func (w *responseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httplib: %T does not implement http.Hijacker", w.ResponseWriter)
+	}
+
+	conn, rw, err := h.Hijack()
+	if err == nil && !w.wroteHeader {
+		// connection is taken over, so treat it as switching protocols
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+
+	return conn, rw, err
+}
+
+type rwPusher struct{ w *responseWriter }
+
+func (p rwPusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// Unwrap returns original http.ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,111 @@
+package httplib
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// plainWriter implements no optional interfaces
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+type hijackWriter struct {
+	http.ResponseWriter
+}
+
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+type pushWriter struct {
+	*httptest.ResponseRecorder
+	pushed string
+}
+
+func (w *pushWriter) Push(target string, _ *http.PushOptions) error {
+	w.pushed = target
+	return nil
+}
+
+func TestWrapResponseWriter_interfaces(t *testing.T) {
+	tests := []struct {
+		name                      string
+		w                         http.ResponseWriter
+		flusher, hijacker, pusher bool
+	}{
+		{"plain", plainWriter{httptest.NewRecorder()}, false, false, false},
+		{"flusher", httptest.NewRecorder(), true, false, false},
+		{"hijacker", hijackWriter{httptest.NewRecorder()}, false, true, false},
+		{"flusher and pusher", &pushWriter{ResponseRecorder: httptest.NewRecorder()}, true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw, w := wrapResponseWriter(tt.w)
+
+			if _, ok := w.(http.Flusher); ok != tt.flusher {
+				t.Errorf("http.Flusher = %v, want %v", ok, tt.flusher)
+			}
+			if _, ok := w.(http.Hijacker); ok != tt.hijacker {
+				t.Errorf("http.Hijacker = %v, want %v", ok, tt.hijacker)
+			}
+			if _, ok := w.(http.Pusher); ok != tt.pusher {
+				t.Errorf("http.Pusher = %v, want %v", ok, tt.pusher)
+			}
+			if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); !ok || u.Unwrap() != tt.w {
+				t.Errorf("Unwrap() does not return wrapped writer")
+			}
+
+			// wrapping again in the same chain reuses recorder
+			if again, w2 := wrapResponseWriter(w); again != rw || w2 != w {
+				t.Errorf("wrapped writer is wrapped again")
+			}
+		})
+	}
+}
+
+func TestResponseWriter_capture(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		size    int64
+	}{
+		{"implicit ok", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello"))
+		}, http.StatusOK, 5},
+		{"explicit status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("{}"))
+			w.Write([]byte("\n"))
+		}, http.StatusCreated, 3},
+		{"status written once", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.WriteHeader(http.StatusInternalServerError)
+		}, http.StatusNotFound, 0},
+		{"flush sends ok", func(w http.ResponseWriter, r *http.Request) {
+			w.(http.Flusher).Flush()
+			w.WriteHeader(http.StatusTeapot)
+		}, http.StatusOK, 0},
+		{"nothing written", func(w http.ResponseWriter, r *http.Request) {}, http.StatusOK, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rw, w := wrapResponseWriter(rec)
+			tt.handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rw.Status() != tt.status || rw.Size() != tt.size {
+				t.Errorf("captured %d/%d bytes, want %d/%d bytes", rw.Status(), rw.Size(), tt.status, tt.size)
+			}
+			if rw.Written() && rec.Code != tt.status {
+				t.Errorf("recorder status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package httplib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Purposes of signed values, MAC of the value signed for one purpose
+// does not verify for another, even if they share the key
+const (
+	signPurposeCursor = "cursor"
+	signPurposeAuth   = "auth"
+	signPurposeCSRF   = "csrf"
+)
+
+var (
+	ErrInvalidSignature = fmt.Errorf("httplib: invalid signature")
+	ErrEmptySigningKey  = fmt.Errorf("httplib: signing key is empty")
+)
+
+// signPayload returns base64 encoded payload followed by its HMAC-SHA256 signature for the purpose
+func signPayload(key []byte, purpose string, payload []byte) (string, error) {
+	if len(key) == 0 {
+		return "", ErrEmptySigningKey
+	}
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(purposeSum(key, purpose, payload)), nil
+}
+
+// verifyPayload checks signature of the value produced by signPayload for the same purpose and returns its payload
+func verifyPayload(key []byte, purpose string, signed string) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptySigningKey
+	}
+
+	idx := strings.LastIndexByte(signed, '.')
+	if idx < 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(signed[:idx])
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	sig, err := enc.DecodeString(signed[idx+1:])
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	if !hmac.Equal(sig, purposeSum(key, purpose, payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	return payload, nil
+}
+
+// purposeSum returns HMAC-SHA256 of payload prefixed with purpose
+func purposeSum(key []byte, purpose string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func hmacSum(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,281 @@
+package httplib
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/rovergulf/utils/response"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ContentTypeNDJSON is a media type of newline delimited JSON
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// Iterator yields list items one by one
+type Iterator interface {
+	// Next advances iterator to the next item, it returns false when there are no more items or on error
+	Next() bool
+	// Value returns current item
+	Value() (interface{}, error)
+	// Err returns error occurred during iteration
+	Err() error
+}
+
+// RowsIterator returns Iterator over query rows, closing rows once iteration is finished.
+// Items are made by scan, or are maps of column name to value if scan is nil.
+func RowsIterator(rows pgx.Rows, scan func(rows pgx.Rows) (interface{}, error)) Iterator {
+	return &rowsIterator{rows: rows, scan: scan}
+}
+
+type rowsIterator struct {
+	rows pgx.Rows
+	scan func(rows pgx.Rows) (interface{}, error)
+}
+
+func (it *rowsIterator) Next() bool {
+	return it.rows.Next()
+}
+
+func (it *rowsIterator) Value() (interface{}, error) {
+	if it.scan != nil {
+		return it.scan(it.rows)
+	}
+
+	values, err := it.rows.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	item := make(map[string]interface{}, len(values))
+	for i, fd := range it.rows.FieldDescriptions() {
+		item[string(fd.Name)] = values[i]
+	}
+
+	return item, nil
+}
+
+func (it *rowsIterator) Err() error {
+	return it.rows.Err()
+}
+
+func (it *rowsIterator) Close() {
+	it.rows.Close()
+}
+
+// SliceIterator returns Iterator over slice items
+func SliceIterator(items []interface{}) Iterator {
+	return &sliceIterator{items: items, idx: -1}
+}
+
+type sliceIterator struct {
+	items []interface{}
+	idx   int
+}
+
+func (it *sliceIterator) Next() bool {
+	it.idx++
+	return it.idx < len(it.items)
+}
+
+func (it *sliceIterator) Value() (interface{}, error) {
+	return it.items[it.idx], nil
+}
+
+func (it *sliceIterator) Err() error {
+	return nil
+}
+
+// DefaultStreamer flushes every 100 items or once a second
+var DefaultStreamer = Streamer{FlushEvery: 100, FlushInterval: time.Second}
+
+// Streamer writes list responses item by item without holding the whole list in memory
+type Streamer struct {
+	// FlushEvery is a number of items written between flushes, zero disables it
+	FlushEvery int
+	// FlushInterval is a max time between flushes, zero disables it
+	FlushInterval time.Duration
+}
+
+// StreamList streams items with DefaultStreamer, see Streamer.Stream
+func StreamList(w http.ResponseWriter, r *http.Request, it Iterator) error {
+	return DefaultStreamer.Stream(w, r, it)
+}
+
+// Stream writes items as NDJSON if request accepts application/x-ndjson,
+// or as ListResult JSON object otherwise.
+//
+// Error occurred before first item is written is responded with problem details.
+// Later errors are appended as "error" member of ListResult or as the last NDJSON line,
+// since status is already sent. Streaming stops once request context is done,
+// that is when client disconnects. It returns error that interrupted streaming.
+func (s Streamer) Stream(w http.ResponseWriter, r *http.Request, it Iterator) error {
+	if c, ok := it.(interface{ Close() }); ok {
+		defer c.Close()
+	}
+
+	sw := &streamWriter{
+		w:       w,
+		enc:     json.NewEncoder(w),
+		ndjson:  acceptsNDJSON(r),
+		flusher: flusher(w),
+	}
+	ctx := r.Context()
+	lastFlush := time.Now()
+
+	var err error
+	for err == nil && it.Next() {
+		if err = ctx.Err(); err != nil {
+			// client is gone, nothing else could be written
+			return err
+		}
+
+		var item interface{}
+		if item, err = it.Value(); err != nil {
+			break
+		}
+
+		// item is encoded before anything is written, so encoding error
+		// is reported as streaming error and leaves output valid
+		var data []byte
+		if data, err = json.Marshal(item); err != nil {
+			break
+		}
+
+		if err = sw.writeItem(data); err != nil {
+			return err
+		}
+
+		if (s.FlushEvery > 0 && sw.count%s.FlushEvery == 0) ||
+			(s.FlushInterval > 0 && time.Since(lastFlush) >= s.FlushInterval) {
+			sw.flush()
+			lastFlush = time.Now()
+		}
+	}
+
+	if err == nil {
+		err = it.Err()
+	}
+
+	if err != nil && !sw.started {
+		ErrorResponse(w, r, err)
+		return err
+	}
+
+	if werr := sw.finish(r, err); werr != nil && err == nil {
+		err = werr
+	}
+
+	return err
+}
+
+type streamWriter struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	ndjson  bool
+	flusher http.Flusher
+	started bool
+	count   int
+}
+
+func (sw *streamWriter) start() error {
+	sw.started = true
+
+	if sw.ndjson {
+		sw.w.Header().Set("Content-Type", ContentTypeNDJSON)
+		sw.w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	sw.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	sw.w.WriteHeader(http.StatusOK)
+	_, err := sw.w.Write([]byte(`{"results":[`))
+	return err
+}
+
+// writeItem writes encoded item, preceded by separator in JSON array
+func (sw *streamWriter) writeItem(data []byte) error {
+	if !sw.started {
+		if err := sw.start(); err != nil {
+			return err
+		}
+	}
+
+	if sw.count > 0 && !sw.ndjson {
+		if _, err := sw.w.Write([]byte{','}); err != nil {
+			return err
+		}
+	}
+
+	sw.count++
+	if _, err := sw.w.Write(data); err != nil {
+		return err
+	}
+	_, err := sw.w.Write([]byte{'\n'})
+	return err
+}
+
+// finish closes ListResult object and reports streaming error, if any
+func (sw *streamWriter) finish(r *http.Request, streamErr error) error {
+	if !sw.started {
+		if err := sw.start(); err != nil {
+			return err
+		}
+	}
+
+	var apiErr *ApiError
+	if streamErr != nil {
+		// FromError returns a copy, so errors shared between requests are not modified
+		apiErr = response.FromError(streamErr)
+		apiErr.RequestId = RequestIDFromContext(r.Context())
+	}
+
+	var err error
+	if sw.ndjson {
+		if apiErr != nil {
+			err = sw.enc.Encode(struct {
+				Error *ApiError `json:"error"`
+			}{apiErr})
+		}
+	} else {
+		_, err = fmt.Fprintf(sw.w, `],"count":%d,"has_prev":false,"has_next":false`, sw.count)
+		if err == nil && apiErr != nil {
+			if _, err = sw.w.Write([]byte(`,"error":`)); err == nil {
+				err = sw.enc.Encode(apiErr)
+			}
+		}
+		if err == nil {
+			_, err = sw.w.Write([]byte("}\n"))
+		}
+	}
+
+	sw.flush()
+	return err
+}
+
+func (sw *streamWriter) flush() {
+	if sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+}
+
+func flusher(w http.ResponseWriter) http.Flusher {
+	f, _ := w.(http.Flusher)
+	return f
+}
+
+// acceptsNDJSON reports whether NDJSON is preferred over JSON by request Accept header
+func acceptsNDJSON(r *http.Request) bool {
+	for _, qv := range response.ParseQualityValues(r.Header.Get("Accept")) {
+		switch {
+		case qv.Q <= 0:
+			continue
+		case qv.Value == ContentTypeNDJSON || strings.HasSuffix(qv.Value, "/ndjson"):
+			return true
+		case qv.Value == "application/json":
+			return false
+		}
+	}
+	return false
+}
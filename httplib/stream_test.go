@@ -0,0 +1,155 @@
+package httplib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/rovergulf/utils/response"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type failingIterator struct {
+	Iterator
+	failAt int
+	n      int
+	err    error
+}
+
+func (it *failingIterator) Value() (interface{}, error) {
+	it.n++
+	if it.n == it.failAt {
+		if it.err != nil {
+			return nil, it.err
+		}
+		return nil, fmt.Errorf("scan failed")
+	}
+	return it.Iterator.Value()
+}
+
+func TestStreamer_Stream(t *testing.T) {
+	items := []interface{}{map[string]int{"id": 1}, map[string]int{"id": 2}, map[string]int{"id": 3}}
+
+	t.Run("json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := (Streamer{FlushEvery: 2}).Stream(w, r, SliceIterator(items)); err != nil {
+			t.Fatal(err)
+		}
+
+		var res struct {
+			Results []map[string]int `json:"results"`
+			Count   int              `json:"count"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("invalid JSON %s: %s", w.Body.String(), err)
+		}
+		if res.Count != 3 || len(res.Results) != 3 || res.Results[2]["id"] != 3 {
+			t.Errorf("unexpected result: %s", w.Body.String())
+		}
+		if !w.Flushed {
+			t.Errorf("response is not flushed")
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "application/json;q=0.5, application/x-ndjson")
+		if err := StreamList(w, r, SliceIterator(items)); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := w.Header().Get("Content-Type"); got != ContentTypeNDJSON {
+			t.Errorf("Content-Type = %s, want %s", got, ContentTypeNDJSON)
+		}
+		if want := "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n"; w.Body.String() != want {
+			t.Errorf("body = %q, want %q", w.Body.String(), want)
+		}
+	})
+
+	t.Run("error before first item", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := StreamList(w, r, &failingIterator{Iterator: SliceIterator(items), failAt: 1}); err == nil {
+			t.Fatal("Stream() error = nil")
+		}
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+		}
+	})
+
+	t.Run("error after first item", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := StreamList(w, r, &failingIterator{Iterator: SliceIterator(items), failAt: 2}); err == nil {
+			t.Fatal("Stream() error = nil")
+		}
+
+		var res map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("invalid JSON %s: %s", w.Body.String(), err)
+		}
+		if _, ok := res["error"]; !ok || string(res["count"]) != "1" {
+			t.Errorf("unexpected result: %s", w.Body.String())
+		}
+	})
+
+	t.Run("encoding error after first item", func(t *testing.T) {
+		invalid := []interface{}{map[string]int{"id": 1}, map[string]interface{}{"id": make(chan int)}, map[string]int{"id": 3}}
+
+		for _, accept := range []string{"application/json", ContentTypeNDJSON} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", accept)
+			if err := StreamList(w, r, SliceIterator(invalid)); err == nil {
+				t.Fatal("Stream() error = nil")
+			}
+
+			lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+			if accept == ContentTypeNDJSON {
+				if len(lines) != 2 || !strings.HasPrefix(lines[1], `{"error":`) {
+					t.Errorf("unexpected NDJSON: %q", w.Body.String())
+				}
+				continue
+			}
+
+			var res map[string]json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("invalid JSON %s: %s", w.Body.String(), err)
+			}
+			if _, ok := res["error"]; !ok || string(res["count"]) != "1" {
+				t.Errorf("unexpected result: %s", w.Body.String())
+			}
+		}
+	})
+
+	t.Run("shared error", func(t *testing.T) {
+		errShared := response.NewApiError(response.ErrCodeUnavailable, "unavailable")
+		it := &failingIterator{Iterator: SliceIterator(items), failAt: 2, err: errShared}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(ContextWithRequestInfo(r.Context(), &RequestInfo{ID: "stream"}))
+		StreamList(httptest.NewRecorder(), r, it)
+
+		if errShared.RequestId != "" {
+			t.Errorf("shared error is modified, request id %q", errShared.RequestId)
+		}
+	})
+
+	t.Run("client disconnected", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		if err := StreamList(w, r, SliceIterator(items)); err != context.Canceled {
+			t.Errorf("Stream() error = %v, want context.Canceled", err)
+		}
+		if strings.Contains(w.Body.String(), "id") {
+			t.Errorf("items are written after disconnect: %s", w.Body.String())
+		}
+	})
+}
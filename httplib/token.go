@@ -10,24 +10,74 @@ const (
 	PrefixBearer = "Bearer "
 )
 
-func ExtractTokenFromRequest(r *http.Request) (string, error) {
-	cookie, _ := r.Cookie(CookieName)
-	token := cookie.Value
-	if len(token) > 0 {
-		return token, nil
-	}
+var ErrNoToken = fmt.Errorf("not a token cookie, nor auth header, nor query parameter specified")
+
+// TokenSource is a place in request token is looked up in
+type TokenSource int
+
+const (
+	TokenFromCookie TokenSource = iota
+	TokenFromHeader
+	TokenFromQuery
+)
+
+var defaultTokenSources = []TokenSource{TokenFromCookie, TokenFromHeader, TokenFromQuery}
 
-	token = GetAuthorizationTokenFromRequestHeader(r, PrefixBearer)
-	if len(token) > 0 {
-		return token, nil
+// TokenExtractor looks up token in request sources in the specified order
+type TokenExtractor struct {
+	// Sources defaults to cookie, authorization header and query parameter
+	Sources []TokenSource
+	// Session is used to read cookie token, if set
+	Session *SessionCookies
+	// CookieName defaults to CookieName package variable, ignored if Session is set
+	CookieName string
+	// QueryParam defaults to CookieName package variable
+	QueryParam string
+	// HeaderPrefix defaults to PrefixBearer
+	HeaderPrefix string
+}
+
+// Extract returns the first non-empty token found, or ErrNoToken
+func (e TokenExtractor) Extract(r *http.Request) (string, error) {
+	sources := e.Sources
+	if len(sources) == 0 {
+		sources = defaultTokenSources
 	}
 
-	token = r.FormValue(CookieName)
-	if len(token) == 0 || token == " " {
-		return "", fmt.Errorf("not a token cookie, nor auth header, nor query parameter specified")
+	for _, src := range sources {
+		var token string
+		switch src {
+		case TokenFromCookie:
+			if e.Session != nil {
+				token, _ = e.Session.Get(r)
+			} else {
+				token = getTokenFromCookie(r, valueOrDefault(e.CookieName, CookieName))
+			}
+		case TokenFromHeader:
+			token = GetAuthorizationTokenFromRequestHeader(r, e.HeaderPrefix)
+		case TokenFromQuery:
+			// FormValue would read urlencoded request body as well
+			token = r.URL.Query().Get(valueOrDefault(e.QueryParam, CookieName))
+		}
+
+		if token = strings.TrimSpace(token); len(token) > 0 {
+			return token, nil
+		}
 	}
 
-	return token, nil
+	return "", ErrNoToken
+}
+
+func ExtractTokenFromRequest(r *http.Request) (string, error) {
+	return TokenExtractor{}.Extract(r)
+}
+
+func getTokenFromCookie(r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
 }
 
 func GetAuthorizationTokenFromRequestHeader(r *http.Request, prefix string) string {
@@ -41,3 +91,10 @@ func GetAuthorizationTokenFromRequestHeader(r *http.Request, prefix string) stri
 	}
 	return authHeader[len(prefix):]
 }
+
+func valueOrDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
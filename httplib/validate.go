@@ -0,0 +1,226 @@
+package httplib
+
+import (
+	"fmt"
+	"github.com/rovergulf/utils"
+	"github.com/rovergulf/utils/response"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes invalid field of the validated value
+type FieldError = response.FieldError
+
+// ValidationErrors contains every invalid field of the validated value
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks struct fields by comma separated rules of the `validate` tag:
+//
+//	required  - value is not a zero value
+//	min=N     - minimum length of strings, slices and maps or minimum numeric value
+//	max=N     - maximum length of strings, slices and maps or maximum numeric value
+//	email     - string is a valid email, see utils.ValidateEmail
+//	phone     - string is a valid phone number, see utils.ValidatePhoneNumber
+//
+// Nested structs, pointers and slices of structs are validated recursively,
+// fields are named after their json tags.
+// Rules other than required skip nil pointers and empty strings or collections,
+// while numeric zero is checked, so optional numbers should be pointers.
+// It returns ValidationErrors if any field is invalid.
+func Validate(v interface{}) error {
+	var errs ValidationErrors
+	validateValue(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateValue(v reflect.Value, path string, errs *ValidationErrors) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue // unexported
+			}
+
+			name := fieldName(f)
+			if name == "-" {
+				continue
+			}
+
+			fieldPath := name
+			if f.Anonymous && f.Tag.Get("json") == "" {
+				fieldPath = path // embedded fields are promoted
+			} else if path != "" {
+				fieldPath = path + "." + name
+			}
+
+			fv := v.Field(i)
+			if tag := f.Tag.Get("validate"); tag != "" && tag != "-" {
+				for _, msg := range validateField(fv, tag) {
+					*errs = append(*errs, FieldError{Field: fieldPath, Message: msg})
+				}
+			}
+
+			validateValue(fv, fieldPath, errs)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func fieldName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func validateField(v reflect.Value, tag string) []string {
+	var msgs []string
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		name, arg := rule, ""
+		if idx := strings.IndexByte(rule, '='); idx >= 0 {
+			name, arg = rule[:idx], rule[idx+1:]
+		}
+
+		if name == "required" {
+			if isZeroValue(v) {
+				// the rest of the rules make no sense for missing value
+				return append(msgs, "is required")
+			}
+			continue
+		}
+
+		// optional missing values are not validated, numeric zero is validated as any other number
+		if isEmptyValue(v) {
+			continue
+		}
+
+		if msg := checkRule(indirect(v), name, arg); msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs
+}
+
+func checkRule(v reflect.Value, rule, arg string) string {
+	switch rule {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Sprintf("has invalid %s rule argument %q", rule, arg)
+		}
+
+		n, isLength, ok := measure(v)
+		if !ok {
+			return ""
+		}
+
+		switch {
+		case rule == "min" && n < limit && isLength:
+			return fmt.Sprintf("must be at least %s characters or items long", arg)
+		case rule == "min" && n < limit:
+			return fmt.Sprintf("must be greater than or equal to %s", arg)
+		case rule == "max" && n > limit && isLength:
+			return fmt.Sprintf("must be at most %s characters or items long", arg)
+		case rule == "max" && n > limit:
+			return fmt.Sprintf("must be less than or equal to %s", arg)
+		}
+
+	case "email":
+		if v.Kind() == reflect.String && !utils.ValidateEmail(strings.ToLower(v.String())) {
+			return "must be a valid email address"
+		}
+
+	case "phone":
+		if v.Kind() == reflect.String && !utils.ValidatePhoneNumber(v.String()) {
+			return "must be a valid phone number in international format"
+		}
+	}
+
+	return ""
+}
+
+// measure returns length of strings and collections or numeric value
+func measure(v reflect.Value) (float64, bool, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	default:
+		return 0, false, false
+	}
+}
+
+func isZeroValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// isEmptyValue reports whether v is nil, empty string or empty collection
+func isEmptyValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return v
+}
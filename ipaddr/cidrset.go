@@ -0,0 +1,247 @@
+package ipaddr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cidrNode is a node of binary prefix trie, rule is set for nodes terminating a network
+type cidrNode struct {
+	children [2]*cidrNode
+	rule     *net.IPNet
+}
+
+type cidrTrie struct {
+	v4, v6 *cidrNode
+	size   int
+}
+
+func newCIDRTrie() *cidrTrie {
+	return &cidrTrie{v4: new(cidrNode), v6: new(cidrNode)}
+}
+
+func (t *cidrTrie) insert(n *net.IPNet) {
+	node := t.v6
+	ip := n.IP
+	if ip4 := ip.To4(); ip4 != nil && len(n.Mask) == net.IPv4len {
+		node, ip = t.v4, ip4
+	}
+
+	ones, _ := n.Mask.Size()
+	for i := 0; i < ones; i++ {
+		bit := ip[i/8] >> (7 - uint(i%8)) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = new(cidrNode)
+		}
+		node = node.children[bit]
+	}
+
+	if node.rule == nil {
+		t.size++
+	}
+	node.rule = n
+}
+
+// match returns the most specific network containing ip
+func (t *cidrTrie) match(ip net.IP) *net.IPNet {
+	node := t.v6
+	if ip4 := ip.To4(); ip4 != nil {
+		node, ip = t.v4, ip4
+	} else if len(ip) != net.IPv6len {
+		return nil
+	}
+
+	match := node.rule
+	for i := 0; i < len(ip)*8; i++ {
+		node = node.children[ip[i/8]>>(7-uint(i%8))&1]
+		if node == nil {
+			break
+		}
+		if node.rule != nil {
+			match = node.rule
+		}
+	}
+
+	return match
+}
+
+// CIDRSet is a set of IPv4 and IPv6 networks backed by prefix trie,
+// lookups take time proportional to address length regardless of set size.
+// It is safe for concurrent use.
+type CIDRSet struct {
+	mx      sync.RWMutex
+	trie    *cidrTrie
+	path    string
+	modTime time.Time
+}
+
+// NewCIDRSet returns set of CIDRs or single addresses
+func NewCIDRSet(cidrs ...string) (*CIDRSet, error) {
+	s := &CIDRSet{trie: newCIDRTrie()}
+	if err := s.Add(cidrs...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadCIDRFile returns set of networks listed in file, one per line.
+// Empty lines and "#" comments are ignored. Set could be updated with Reload or WatchFile.
+func LoadCIDRFile(path string) (*CIDRSet, error) {
+	s := &CIDRSet{trie: newCIDRTrie(), path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add adds CIDRs or single addresses to the set
+func (s *CIDRSet) Add(cidrs ...string) error {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		n, err := ParseCIDR(c)
+		if err != nil {
+			return err
+		}
+		networks = append(networks, n)
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for _, n := range networks {
+		s.trie.insert(n)
+	}
+	return nil
+}
+
+// Reload replaces set networks with ones listed in the file, set is left intact on error
+func (s *CIDRSet) Reload() error {
+	if s.path == "" {
+		return fmt.Errorf("ipaddr: CIDR set is not loaded from file")
+	}
+
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	trie := newCIDRTrie()
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if idx := strings.IndexByte(text, '#'); idx >= 0 {
+			text = text[:idx]
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+
+		n, err := ParseCIDR(text)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		trie.insert(n)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	s.mx.Lock()
+	s.trie = trie
+	s.modTime = fi.ModTime()
+	s.mx.Unlock()
+	return nil
+}
+
+// WatchFile reloads set once file modification time changes, checking it every interval
+// until ctx is done. Reload errors are passed to onError, if it is not nil.
+func (s *CIDRSet) WatchFile(ctx context.Context, interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// modification time of the file failed to load, so error is reported once
+	var failed time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mx.RLock()
+		modTime := s.modTime
+		s.mx.RUnlock()
+
+		fi, err := os.Stat(s.path)
+		if err == nil && (fi.ModTime().Equal(modTime) || fi.ModTime().Equal(failed)) {
+			continue
+		}
+		if err == nil {
+			if err = s.Reload(); err != nil {
+				failed = fi.ModTime()
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// Contains reports whether ip belongs to any network of the set
+func (s *CIDRSet) Contains(ip net.IP) bool {
+	_, ok := s.Match(ip)
+	return ok
+}
+
+// Match returns the most specific network of the set containing ip
+func (s *CIDRSet) Match(ip net.IP) (*net.IPNet, bool) {
+	if ip == nil {
+		return nil, false
+	}
+
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	n := s.trie.match(ip)
+	return n, n != nil
+}
+
+// Len returns number of networks in the set
+func (s *CIDRSet) Len() int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.trie.size
+}
+
+// ParseCIDR parses network in CIDR notation or single address as a network of one host
+func ParseCIDR(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip := ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("ipaddr: invalid address %q", s)
+		}
+
+		bits := 8 * len(ip)
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("ipaddr: invalid network %q: %w", s, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,93 @@
+package ipaddr_test
+
+import (
+	"context"
+	"github.com/rovergulf/utils/ipaddr"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCIDRSet_Match(t *testing.T) {
+	s, err := ipaddr.NewCIDRSet("10.0.0.0/8", "10.1.0.0/16", "192.168.1.10", "2001:db8::/32", "0.0.0.0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", s.Len())
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"10.2.3.4", "10.0.0.0/8"},
+		{"10.1.3.4", "10.1.0.0/16"},
+		{"192.168.1.10", "192.168.1.10/32"},
+		{"8.8.8.8", "0.0.0.0/0"},
+		{"2001:db8:1::1", "2001:db8::/32"},
+		{"2001:db9::1", ""},
+	}
+
+	for _, tt := range tests {
+		n, ok := s.Match(net.ParseIP(tt.ip))
+		got := ""
+		if ok {
+			got = n.String()
+		}
+		if got != tt.want {
+			t.Errorf("Match(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCIDRFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allow.txt")
+	if err := ioutil.WriteFile(path, []byte("# office\n203.0.113.0/24\n\n2001:db8::/48 # vpn\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := ipaddr.LoadCIDRFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Contains(net.ParseIP("203.0.113.9")) || !s.Contains(net.ParseIP("2001:db8::1")) {
+		t.Fatalf("loaded set does not contain listed networks")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.WatchFile(ctx, 10*time.Millisecond, func(err error) { t.Error(err) })
+		close(done)
+	}()
+
+	if err := ioutil.WriteFile(path, []byte("198.51.100.0/24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+
+	deadline := time.Now().Add(time.Second)
+	for s.Contains(net.ParseIP("203.0.113.9")) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s.Contains(net.ParseIP("203.0.113.9")) || !s.Contains(net.ParseIP("198.51.100.1")) {
+		t.Errorf("set is not reloaded")
+	}
+	cancel()
+	<-done
+
+	if err := ioutil.WriteFile(path, []byte("not a network\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(); err == nil {
+		t.Errorf("Reload() of invalid file error = nil")
+	}
+	if !s.Contains(net.ParseIP("198.51.100.1")) {
+		t.Errorf("set is changed by failed reload")
+	}
+}
@@ -0,0 +1,208 @@
+package ipaddr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	ua "github.com/rovergulf/utils/useragent"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Fingerprint components
+const (
+	FingerprintIP        = "ip"
+	FingerprintUserAgent = "ua"
+	FingerprintLanguage  = "lang"
+	// fingerprintHeaderPrefix prefixes components of selected headers, e.g. "header:sec-ch-ua"
+	fingerprintHeaderPrefix = "header:"
+)
+
+// ErrEmptyFingerprintKey is returned if fingerprint builder has no HMAC key
+var ErrEmptyFingerprintKey = fmt.Errorf("ipaddr: fingerprint key is empty")
+
+// Fingerprint identifies client by keyed hashes of request properties
+type Fingerprint struct {
+	// Hash is a hash of every component
+	Hash string `json:"hash"`
+	// Components are hashes of separate properties, keyed by component name
+	Components map[string]string `json:"components"`
+}
+
+func (f Fingerprint) String() string {
+	return f.Hash
+}
+
+// FingerprintBuilder builds request fingerprints stable across minor client changes:
+// IP address is reduced to network prefix, so it survives address change within
+// provider network, and only browser family and OS of User-Agent are used, so it survives updates.
+type FingerprintBuilder struct {
+	// Key is HMAC key, fingerprints are comparable only if built with the same key
+	Key []byte
+	// Resolver resolves client address from trusted proxy headers,
+	// connection address is used if it is nil
+	Resolver *Resolver
+	// IPv4Prefix and IPv6Prefix are network prefix lengths client address is reduced to,
+	// IP address is not used if both are zero
+	IPv4Prefix int
+	IPv6Prefix int
+	// UserAgent enables parsed browser family and OS component
+	UserAgent bool
+	// Language enables Accept-Language component
+	Language bool
+	// Headers are names of additional headers used as components
+	Headers []string
+	// Weights of components in Similarity, components without weight have weight of 1
+	Weights map[string]float64
+}
+
+// NewFingerprintBuilder returns builder using /24 and /64 IP prefixes, User-Agent and Accept-Language,
+// with address weighted less than the rest, since it changes more often
+func NewFingerprintBuilder(key []byte) (*FingerprintBuilder, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyFingerprintKey
+	}
+
+	return &FingerprintBuilder{
+		Key:        key,
+		IPv4Prefix: 24,
+		IPv6Prefix: 64,
+		UserAgent:  true,
+		Language:   true,
+		Weights: map[string]float64{
+			FingerprintIP:        0.5,
+			FingerprintUserAgent: 2,
+		},
+	}, nil
+}
+
+// Build returns fingerprint of the request
+func (b *FingerprintBuilder) Build(r *http.Request) (Fingerprint, error) {
+	if len(b.Key) == 0 {
+		return Fingerprint{}, ErrEmptyFingerprintKey
+	}
+
+	values := b.components(r)
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fp := Fingerprint{Components: make(map[string]string, len(values))}
+	all := hmac.New(sha256.New, b.Key)
+	for _, name := range names {
+		fp.Components[name] = b.hash(name, values[name])
+		all.Write([]byte(name + "=" + values[name] + "\n"))
+	}
+	fp.Hash = hex.EncodeToString(all.Sum(nil))
+
+	return fp, nil
+}
+
+// components returns normalized values of enabled components
+func (b *FingerprintBuilder) components(r *http.Request) map[string]string {
+	values := make(map[string]string)
+
+	if b.IPv4Prefix > 0 || b.IPv6Prefix > 0 {
+		values[FingerprintIP] = b.ipPrefix(r)
+	}
+
+	if b.UserAgent {
+		agent := ua.ParseRequest(r)
+		values[FingerprintUserAgent] = agent.Name + "/" + agent.OS
+	}
+
+	if b.Language {
+		values[FingerprintLanguage] = normalizeLanguages(r.Header.Get("Accept-Language"))
+	}
+
+	for _, h := range b.Headers {
+		values[fingerprintHeaderPrefix+strings.ToLower(h)] = strings.TrimSpace(r.Header.Get(h))
+	}
+
+	return values
+}
+
+func (b *FingerprintBuilder) ipPrefix(r *http.Request) string {
+	var ip net.IP
+	if b.Resolver != nil {
+		ip, _ = b.Resolver.Resolve(r)
+	} else {
+		// headers are not trusted without resolver, since any client is able to set them
+		ip = ParseIP(r.RemoteAddr)
+	}
+
+	if ip == nil {
+		return ""
+	}
+
+	bits, prefix := 8*net.IPv6len, b.IPv6Prefix
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits, prefix = ip4, 8*net.IPv4len, b.IPv4Prefix
+	}
+	if prefix <= 0 || prefix > bits {
+		prefix = bits
+	}
+
+	n := net.IPNet{IP: ip.Mask(net.CIDRMask(prefix, bits)), Mask: net.CIDRMask(prefix, bits)}
+	return n.String()
+}
+
+// hash returns truncated HMAC of the component, bound to its name
+func (b *FingerprintBuilder) hash(name, value string) string {
+	mac := hmac.New(sha256.New, b.Key)
+	mac.Write([]byte(name + "=" + value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Similarity returns weighted share of matching components of two fingerprints, from 0 to 1.
+// Components present in only one of fingerprints are counted as different.
+func (b *FingerprintBuilder) Similarity(x, y Fingerprint) float64 {
+	if x.Hash != "" && x.Hash == y.Hash {
+		return 1
+	}
+
+	var total, matched float64
+	count := func(name string, equal bool) {
+		w, ok := b.Weights[name]
+		if !ok {
+			w = 1
+		}
+		total += w
+		if equal {
+			matched += w
+		}
+	}
+
+	for name, h := range x.Components {
+		other, ok := y.Components[name]
+		count(name, ok && hmac.Equal([]byte(h), []byte(other)))
+	}
+	for name := range y.Components {
+		if _, ok := x.Components[name]; !ok {
+			count(name, false)
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return matched / total
+}
+
+// normalizeLanguages returns lower cased language ranges of Accept-Language in header order, without weights
+func normalizeLanguages(header string) string {
+	var langs []string
+	for _, part := range strings.Split(header, ",") {
+		lang := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		if lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	return strings.Join(langs, ",")
+}
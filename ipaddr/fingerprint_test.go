@@ -0,0 +1,105 @@
+package ipaddr_test
+
+import (
+	"github.com/rovergulf/utils/ipaddr"
+	"net/http"
+	"testing"
+)
+
+const (
+	testChromeWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/101.0.4951.67 Safari/537.36"
+	testChromeUpdated = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/102.0.5005.63 Safari/537.36"
+	testFirefoxLinux  = "Mozilla/5.0 (X11; Linux x86_64; rv:100.0) Gecko/20100101 Firefox/100.0"
+)
+
+func fingerprintRequest(remoteAddr, userAgent, lang string) *http.Request {
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	r.Header.Set("User-Agent", userAgent)
+	r.Header.Set("Accept-Language", lang)
+	return r
+}
+
+func buildFingerprint(t *testing.T, b *ipaddr.FingerprintBuilder, r *http.Request) ipaddr.Fingerprint {
+	t.Helper()
+	fp, err := b.Build(r)
+	if err != nil {
+		t.Fatalf("Build() error = %s", err)
+	}
+	return fp
+}
+
+func TestFingerprintBuilder(t *testing.T) {
+	b, err := ipaddr.NewFingerprintBuilder([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Resolver, _ = ipaddr.NewResolver()
+
+	base := buildFingerprint(t, b, fingerprintRequest("203.0.113.10:1000", testChromeWindows, "en-US,en;q=0.9"))
+
+	tests := []struct {
+		name    string
+		r       *http.Request
+		same    bool
+		minimum float64
+		maximum float64
+	}{
+		{"same network and browser update", fingerprintRequest("203.0.113.99:2000", testChromeUpdated, "en-US, en;q=0.9"), true, 1, 1},
+		{"network changed", fingerprintRequest("198.51.100.1:1000", testChromeWindows, "en-US,en;q=0.9"), false, 0.8, 0.9},
+		{"another browser", fingerprintRequest("203.0.113.10:1000", testFirefoxLinux, "en-US,en;q=0.9"), false, 0.3, 0.5},
+		{"nothing in common", fingerprintRequest("[2001:db8::1]:1000", testFirefoxLinux, "de"), false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := buildFingerprint(t, b, tt.r)
+			if (fp.Hash == base.Hash) != tt.same {
+				t.Errorf("hash equality = %v, want %v", fp.Hash == base.Hash, tt.same)
+			}
+
+			s := b.Similarity(base, fp)
+			if s < tt.minimum || s > tt.maximum {
+				t.Errorf("Similarity() = %f, want within [%f, %f]", s, tt.minimum, tt.maximum)
+			}
+		})
+	}
+
+	other, err := ipaddr.NewFingerprintBuilder([]byte("another secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Resolver = b.Resolver
+	if fp := buildFingerprint(t, other, fingerprintRequest("203.0.113.10:1000", testChromeWindows, "en-US,en;q=0.9")); fp.Hash == base.Hash {
+		t.Errorf("fingerprints built with different keys are equal")
+	}
+}
+
+func TestFingerprintBuilder_noResolver(t *testing.T) {
+	b, err := ipaddr.NewFingerprintBuilder([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := buildFingerprint(t, b, fingerprintRequest("203.0.113.10:1000", testChromeWindows, "en-US"))
+
+	// client pretends to come from another network
+	r := fingerprintRequest("203.0.113.10:2000", testChromeWindows, "en-US")
+	r.Header.Set(ipaddr.XForwardedFor, "198.51.100.1")
+	r.Header.Set(ipaddr.CFConnectingIp, "198.51.100.1")
+
+	if fp := buildFingerprint(t, b, r); fp.Hash != base.Hash {
+		t.Errorf("proxy headers are trusted without resolver")
+	}
+}
+
+func TestFingerprintBuilder_emptyKey(t *testing.T) {
+	if _, err := ipaddr.NewFingerprintBuilder(nil); err != ipaddr.ErrEmptyFingerprintKey {
+		t.Errorf("NewFingerprintBuilder(nil) error = %v, want %v", err, ipaddr.ErrEmptyFingerprintKey)
+	}
+
+	b := &ipaddr.FingerprintBuilder{IPv4Prefix: 24, UserAgent: true}
+	if _, err := b.Build(fingerprintRequest("203.0.113.10:1000", testChromeWindows, "en-US")); err != ipaddr.ErrEmptyFingerprintKey {
+		t.Errorf("Build() with empty key error = %v, want %v", err, ipaddr.ErrEmptyFingerprintKey)
+	}
+}
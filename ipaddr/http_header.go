@@ -74,6 +74,9 @@ func HttpRequestFootprint(r *http.Request) string {
 	return utils.GenerateHashFromString(GetRequestIPAddress(r) + ":" + httpRequestUserAgent(r))
 }
 
+// GetRequestIPAddress returns client address reported by Cloudflare or X-Forwarded-For headers,
+// or RemoteAddr with port. Headers are trusted regardless of the sender,
+// use Resolver if clients may connect to service directly.
 func GetRequestIPAddress(r *http.Request) string {
 	if cloudflareRealIp := HttpCloudflareRealIP(r); len(cloudflareRealIp) > 7 {
 		return cloudflareRealIp
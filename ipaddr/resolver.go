@@ -0,0 +1,213 @@
+package ipaddr
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	Forwarded = "Forwarded"
+	XRealIp   = "X-Real-IP"
+	// RemoteAddr is a source of address taken from connection rather than header
+	RemoteAddr = "RemoteAddr"
+)
+
+// DefaultResolverHeaders are headers checked by Resolver unless configured otherwise.
+// Cloudflare headers are only honored if request came from Cloudflare, see Resolver.TrustCloudflare.
+var DefaultResolverHeaders = []string{CFConnectingIp, Forwarded, XForwardedFor}
+
+// Resolver resolves client IP address of requests passed through trusted proxies.
+// Headers are only taken into account if request came from trusted proxy,
+// so clients connected directly are unable to spoof their address.
+type Resolver struct {
+	// TrustedProxies are networks of proxies allowed to report client address
+	TrustedProxies []*net.IPNet
+	// CloudflareProxies are networks allowed to report client address with
+	// Cf-Connecting-IP and Cf-Real-IP headers, which other proxies pass through as is
+	CloudflareProxies []*net.IPNet
+	// Headers are checked in order, first one holding valid address is used.
+	// Forwarded and X-Forwarded-For are walked right-to-left skipping trusted proxies,
+	// other headers are expected to hold a single address.
+	Headers []string
+}
+
+// NewResolver returns Resolver trusting specified CIDRs or single addresses
+func NewResolver(trustedProxies ...string) (*Resolver, error) {
+	r := &Resolver{Headers: DefaultResolverHeaders}
+	if err := r.AddTrustedProxies(trustedProxies...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// AddTrustedProxies adds CIDRs or single addresses to trusted proxies
+func (r *Resolver) AddTrustedProxies(proxies ...string) error {
+	for _, p := range proxies {
+		n, err := ParseCIDR(p)
+		if err != nil {
+			return err
+		}
+		r.TrustedProxies = append(r.TrustedProxies, n)
+	}
+
+	return nil
+}
+
+// TrustCloudflare adds bundled Cloudflare ranges to trusted and Cloudflare proxies
+func (r *Resolver) TrustCloudflare() {
+	for _, cidr := range CloudflareRanges() {
+		n, err := ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		r.TrustedProxies = append(r.TrustedProxies, n)
+		r.CloudflareProxies = append(r.CloudflareProxies, n)
+	}
+}
+
+// IsTrusted reports whether ip belongs to trusted proxies
+func (r *Resolver) IsTrusted(ip net.IP) bool {
+	return containsIP(r.TrustedProxies, ip)
+}
+
+// IsCloudflare reports whether ip belongs to Cloudflare proxies
+func (r *Resolver) IsCloudflare(ip net.IP) bool {
+	return containsIP(r.CloudflareProxies, ip)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns client IP address and the header it was taken from,
+// RemoteAddr if request came from untrusted address or none of headers holds valid address.
+// It returns nil IP if RemoteAddr is not valid either.
+func (r *Resolver) Resolve(req *http.Request) (net.IP, string) {
+	remote := ParseIP(req.RemoteAddr)
+	if remote == nil || !r.IsTrusted(remote) {
+		return remote, RemoteAddr
+	}
+
+	headers := r.Headers
+	if headers == nil {
+		headers = DefaultResolverHeaders
+	}
+
+	for _, header := range headers {
+		var ip net.IP
+		switch http.CanonicalHeaderKey(header) {
+		case Forwarded:
+			ip = r.walk(forwardedFor(req.Header.Values(Forwarded)))
+		case XForwardedFor:
+			ip = r.walk(splitList(req.Header.Values(XForwardedFor)))
+		case http.CanonicalHeaderKey(CFConnectingIp), http.CanonicalHeaderKey(CFRealIp):
+			if r.IsCloudflare(remote) {
+				ip = ParseIP(req.Header.Get(header))
+			}
+		default:
+			ip = ParseIP(req.Header.Get(header))
+		}
+
+		if ip != nil {
+			return ip, header
+		}
+	}
+
+	return remote, RemoteAddr
+}
+
+// walk returns the rightmost address not belonging to trusted proxies.
+// If every address is trusted, or walk stops at malformed one, the last valid address is returned.
+func (r *Resolver) walk(hops []string) net.IP {
+	var last net.IP
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := ParseIP(hops[i])
+		if ip == nil {
+			break
+		}
+
+		last = ip
+		if !r.IsTrusted(ip) {
+			break
+		}
+	}
+
+	return last
+}
+
+// ParseIP parses address optionally quoted, in brackets, followed by port or IPv6 zone.
+// It returns nil for invalid, unknown or obfuscated addresses.
+func ParseIP(s string) net.IP {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if s == "" {
+		return nil
+	}
+
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	if idx := strings.IndexByte(s, '%'); idx >= 0 {
+		s = s[:idx]
+	}
+
+	ip := net.ParseIP(s)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+func splitList(values []string) []string {
+	var items []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+	return items
+}
+
+// forwardedFor returns "for" parameters of RFC 7239 Forwarded header elements
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitQuoted(values, ',') {
+		var hop string
+		for _, pair := range splitQuoted([]string{element}, ';') {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), "for") {
+				hop = strings.TrimSpace(kv[1])
+			}
+		}
+		// element without "for" still is a hop, so it breaks the walk
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// splitQuoted splits values by sep not enclosed in double quotes
+func splitQuoted(values []string, sep byte) []string {
+	var parts []string
+	for _, v := range values {
+		var quoted bool
+		start := 0
+		for i := 0; i < len(v); i++ {
+			switch {
+			case v[i] == '"':
+				quoted = !quoted
+			case v[i] == sep && !quoted:
+				parts = append(parts, strings.TrimSpace(v[start:i]))
+				start = i + 1
+			}
+		}
+		parts = append(parts, strings.TrimSpace(v[start:]))
+	}
+	return parts
+}
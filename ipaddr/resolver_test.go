@@ -0,0 +1,89 @@
+package ipaddr_test
+
+import (
+	"github.com/rovergulf/utils/ipaddr"
+	"net/http"
+	"testing"
+)
+
+func TestParseIP(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"192.0.2.1", "192.0.2.1"},
+		{"192.0.2.1:8080", "192.0.2.1"},
+		{`"[2001:db8:cafe::17]:4711"`, "2001:db8:cafe::17"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{"fe80::1%eth0", "fe80::1"},
+		{"unknown", "<nil>"},
+		{"_hidden", "<nil>"},
+		{"", "<nil>"},
+	}
+
+	for _, tt := range tests {
+		if got := ipaddr.ParseIP(tt.in).String(); got != tt.want {
+			t.Errorf("ParseIP(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	r, err := ipaddr.NewResolver("10.0.0.0/8", "192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.TrustCloudflare()
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+		wantSource string
+	}{
+		{"direct client", "203.0.113.5:5555", nil, "203.0.113.5", ipaddr.RemoteAddr},
+		{"spoofed by direct client", "203.0.113.5:5555", map[string]string{ipaddr.XForwardedFor: "1.1.1.1", ipaddr.CFConnectingIp: "1.1.1.1"}, "203.0.113.5", ipaddr.RemoteAddr},
+		{"x-forwarded-for", "10.0.0.1:80", map[string]string{ipaddr.XForwardedFor: "1.1.1.1, 203.0.113.7, 10.0.0.2"}, "203.0.113.7", ipaddr.XForwardedFor},
+		{"all hops trusted", "10.0.0.1:80", map[string]string{ipaddr.XForwardedFor: "10.1.1.1, 192.168.1.1"}, "10.1.1.1", ipaddr.XForwardedFor},
+		{"malformed hop", "10.0.0.1:80", map[string]string{ipaddr.XForwardedFor: "203.0.113.7, garbage, 10.0.0.2"}, "10.0.0.2", ipaddr.XForwardedFor},
+		{"forwarded", "[2606:4700::1]:443", map[string]string{ipaddr.Forwarded: `for=192.0.2.43, for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.3`}, "2001:db8:cafe::17", ipaddr.Forwarded},
+		{"cloudflare", "162.158.1.1:443", map[string]string{ipaddr.CFConnectingIp: "198.51.100.4", ipaddr.XForwardedFor: "1.1.1.1"}, "198.51.100.4", ipaddr.CFConnectingIp},
+		{"no headers", "10.0.0.1:80", nil, "10.0.0.1", ipaddr.RemoteAddr},
+		{"cloudflare spoofed through trusted proxy", "10.0.0.1:80", map[string]string{ipaddr.CFConnectingIp: "1.1.1.1", ipaddr.XForwardedFor: "203.0.113.7"}, "203.0.113.7", ipaddr.XForwardedFor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			ip, source := r.Resolve(req)
+			if ip.String() != tt.want || source != tt.wantSource {
+				t.Errorf("Resolve() = %s, %s, want %s, %s", ip, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestResolver_ResolveCloudflareHeadersWithoutCloudflare(t *testing.T) {
+	// deployment trusting its own load balancer only
+	r, err := ipaddr.NewResolver("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Headers = []string{ipaddr.CFConnectingIp, ipaddr.CFRealIp, ipaddr.XRealIp}
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:80"
+	req.Header.Set(ipaddr.CFConnectingIp, "1.1.1.1")
+	req.Header.Set(ipaddr.CFRealIp, "1.1.1.1")
+	req.Header.Set(ipaddr.XRealIp, "203.0.113.7")
+
+	if ip, source := r.Resolve(req); ip.String() != "203.0.113.7" || source != ipaddr.XRealIp {
+		t.Errorf("Resolve() = %s, %s, want 203.0.113.7, %s", ip, source, ipaddr.XRealIp)
+	}
+}
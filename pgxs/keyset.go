@@ -0,0 +1,83 @@
+package pgxs
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+var ErrKeysetMismatch = fmt.Errorf("pgxs: keyset values do not match columns")
+
+// Keyset describes sort columns used for keyset (cursor) pagination.
+// All columns are sorted in the same direction, so row comparison could be used.
+type Keyset struct {
+	Columns []string
+	Desc    bool
+}
+
+// Where returns condition selecting rows after (or before, if backward) specified values,
+// e.g. `("created_at", "id") > ($1, $2)`, along with its arguments.
+// argOffset is a number of arguments already used in the query.
+func (k Keyset) Where(values []interface{}, backward bool, argOffset int) (string, []interface{}, error) {
+	if len(k.Columns) == 0 || len(values) != len(k.Columns) {
+		return "", nil, ErrKeysetMismatch
+	}
+
+	op := ">"
+	if k.Desc != backward {
+		op = "<"
+	}
+
+	columns := make([]string, len(k.Columns))
+	placeholders := make([]string, len(k.Columns))
+	args := make([]interface{}, len(values))
+	for i := range k.Columns {
+		columns[i] = quoteColumn(k.Columns[i])
+		placeholders[i] = fmt.Sprintf("$%d", argOffset+i+1)
+		args[i] = keysetValue(values[i])
+	}
+
+	cond := fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(placeholders, ", "))
+
+	return cond, args, nil
+}
+
+// OrderBy returns ORDER BY expression (without the keyword) for the page.
+// Backward pages are selected in reversed order, so results have to be reversed by caller.
+func (k Keyset) OrderBy(backward bool) string {
+	dir := "ASC"
+	if k.Desc != backward {
+		dir = "DESC"
+	}
+
+	columns := make([]string, len(k.Columns))
+	for i := range k.Columns {
+		columns[i] = quoteColumn(k.Columns[i]) + " " + dir
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// quoteColumn quotes possibly table qualified column name
+func quoteColumn(col string) string {
+	return pgx.Identifier(strings.Split(col, ".")).Sanitize()
+}
+
+// keysetValue converts json.Number decoded from cursor into a type pgx is able to encode
+func keysetValue(v interface{}) interface{} {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+
+	return n.String()
+}
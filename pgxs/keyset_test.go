@@ -0,0 +1,76 @@
+package pgxs
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestKeyset_Where(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyset    Keyset
+		values    []interface{}
+		backward  bool
+		argOffset int
+		cond      string
+		orderBy   string
+		args      []interface{}
+		err       error
+	}{
+		{
+			name:    "forward asc",
+			keyset:  Keyset{Columns: []string{"created_at", "id"}},
+			values:  []interface{}{"2022-05-19", json.Number("42")},
+			cond:    `("created_at", "id") > ($1, $2)`,
+			orderBy: `"created_at" ASC, "id" ASC`,
+			args:    []interface{}{"2022-05-19", int64(42)},
+		},
+		{
+			name:      "backward asc with offset",
+			keyset:    Keyset{Columns: []string{"u.id"}},
+			values:    []interface{}{json.Number("1.5")},
+			backward:  true,
+			argOffset: 2,
+			cond:      `("u"."id") < ($3)`,
+			orderBy:   `"u"."id" DESC`,
+			args:      []interface{}{1.5},
+		},
+		{
+			name:    "forward desc",
+			keyset:  Keyset{Columns: []string{"id"}, Desc: true},
+			values:  []interface{}{int64(7)},
+			cond:    `("id") < ($1)`,
+			orderBy: `"id" DESC`,
+			args:    []interface{}{int64(7)},
+		},
+		{
+			name:   "values mismatch",
+			keyset: Keyset{Columns: []string{"created_at", "id"}},
+			values: []interface{}{1},
+			err:    ErrKeysetMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, args, err := tt.keyset.Where(tt.values, tt.backward, tt.argOffset)
+			if err != tt.err {
+				t.Fatalf("Where() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if cond != tt.cond {
+				t.Errorf("Where() cond = %s, want %s", cond, tt.cond)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Where() args = %v, want %v", args, tt.args)
+			}
+			if orderBy := tt.keyset.OrderBy(tt.backward); orderBy != tt.orderBy {
+				t.Errorf("OrderBy() = %s, want %s", orderBy, tt.orderBy)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package response
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,13 +12,41 @@ const (
 	ErrCodeInvalidArguments
 	ErrCodeAlreadyExists
 	ErrCodeNotFound
+	ErrCodeUnauthenticated
+	ErrCodePermissionDenied
+	ErrCodeTooManyRequests
+	ErrCodeDeadlineExceeded
+	ErrCodeUnavailable
 )
 
+// ContentTypeProblemJSON is a media type of RFC 7807 problem details
+const ContentTypeProblemJSON = "application/problem+json"
+
+// FieldError describes invalid field of the validated value
+type FieldError struct {
+	Field   string `json:"field" yaml:"field"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ApiError is an error response rendered as RFC 7807 problem details.
+// Type, Title, Status, Detail and Instance are standard members,
+// the rest are extension members.
 type ApiError struct {
-	HttpStatus int         `json:"http_status,omitempty"`
-	ErrorCode  int         `json:"code"`
-	Message    interface{} `json:"message"`
-	Timestamp  interface{} `json:"timestamp"`
+	Type       string       `json:"type,omitempty" yaml:"type,omitempty"`
+	Title      string       `json:"title,omitempty" yaml:"title,omitempty"`
+	HttpStatus int          `json:"status,omitempty" yaml:"status,omitempty"`
+	Detail     string       `json:"detail,omitempty" yaml:"detail,omitempty"`
+	Instance   string       `json:"instance,omitempty" yaml:"instance,omitempty"`
+	ErrorCode  int          `json:"code" yaml:"code"`
+	Timestamp  time.Time    `json:"timestamp" yaml:"timestamp"`
+	RequestId  string       `json:"request_id,omitempty" yaml:"request_id,omitempty"`
+	Retryable  bool         `json:"retryable,omitempty" yaml:"retryable,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Stack      string       `json:"stack,omitempty" yaml:"stack,omitempty"`
 }
 
 func (e ApiError) Error() string {
@@ -25,7 +54,10 @@ func (e ApiError) Error() string {
 }
 
 func (e ApiError) String() string {
-	msg := fmt.Sprintf("%s", e.Message)
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
 
 	if e.HttpStatus > 0 {
 		msg = fmt.Sprintf("[HTTP_CODE:%d] %s", e.HttpStatus, msg)
@@ -35,31 +67,62 @@ func (e ApiError) String() string {
 		msg = fmt.Sprintf("[INTERNAL_CODE:%d] %s", e.ErrorCode, msg)
 	}
 
-	if e.Timestamp != nil {
-		msg = fmt.Sprintf("[%s] %s", e.Timestamp, msg)
+	if !e.Timestamp.IsZero() {
+		msg = fmt.Sprintf("[%s] %s", e.Timestamp.Format(time.RFC3339), msg)
 	}
 
-	return fmt.Sprintf("%s", msg)
+	return msg
 }
 
-func HttpCode(errorCode int) int {
-	switch errorCode {
-	case ErrCodeInvalidArguments:
-		return http.StatusBadRequest
-	case ErrCodeAlreadyExists:
-		return http.StatusBadRequest
-	case ErrCodeNotFound:
-		return http.StatusNotFound
-	default:
-		return http.StatusInternalServerError
+// Copy returns a copy of the error, safe to be filled with request details
+func (e *ApiError) Copy() *ApiError {
+	c := *e
+	if e.Errors != nil {
+		c.Errors = append([]FieldError(nil), e.Errors...)
 	}
+	return &c
 }
 
+// Write responds with problem details, HttpStatus defaults to 500.
+// The error itself is not modified, so it may be shared between requests.
+func (e *ApiError) Write(w http.ResponseWriter) error {
+	p := *e
+	if p.HttpStatus == 0 {
+		p.HttpStatus = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.HttpStatus)
+	}
+
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblemJSON)
+	w.WriteHeader(p.HttpStatus)
+	_, err = w.Write(payload)
+	return err
+}
+
+// HttpCode returns HTTP status of the error code registered in DefaultRegistry,
+// unknown codes are reported with 500
+func HttpCode(errorCode int) int {
+	return DefaultRegistry.errorCode(errorCode).HttpStatus
+}
+
+// NewApiError returns ApiError with HTTP status of the internal error code
 func NewApiError(code int, msg string) *ApiError {
+	return NewProblem(HttpCode(code), code, msg)
+}
+
+// NewProblem returns ApiError with explicit HTTP status
+func NewProblem(status, code int, detail string) *ApiError {
 	return &ApiError{
+		Title:      http.StatusText(status),
+		HttpStatus: status,
+		Detail:     detail,
 		ErrorCode:  code,
-		HttpStatus: HttpCode(code),
-		Message:    msg,
-		Timestamp:  time.Now().Format(time.RFC1123),
+		Timestamp:  time.Now().UTC(),
 	}
 }
@@ -0,0 +1,262 @@
+package response
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvResultsField is a name of list results field, such as httplib.ListResult.Results
+const csvResultsField = "Results"
+
+// CSVEncoder encodes lists as CSV table with a header row.
+// It supports slices and structs holding a slice in Results field,
+// columns are named after json tags of struct elements or keys of map elements.
+var CSVEncoder Encoder = csvEncoder{}
+
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (csvEncoder) Supports(v interface{}) bool {
+	_, ok := csvRows(reflect.ValueOf(v))
+	return ok
+}
+
+func (csvEncoder) Encode(w io.Writer, v interface{}) error {
+	rows, ok := csvRows(reflect.ValueOf(v))
+	if !ok {
+		return fmt.Errorf("csv: unsupported type %T", v)
+	}
+
+	var header []string
+	var record func(row reflect.Value) ([]string, error)
+
+	elemType := indirectType(rows.Type().Elem())
+	switch elemType.Kind() {
+	case reflect.Struct:
+		fields := structFields(elemType)
+		for _, f := range fields {
+			header = append(header, f.name)
+		}
+		record = func(row reflect.Value) ([]string, error) {
+			row = indirect(row)
+			rec := make([]string, len(fields))
+			if !row.IsValid() {
+				return rec, nil
+			}
+			for i, f := range fields {
+				fv, ok := fieldByIndex(row, f.index)
+				if !ok {
+					continue
+				}
+				cell, err := csvCell(fv)
+				if err != nil {
+					return nil, err
+				}
+				rec[i] = cell
+			}
+			return rec, nil
+		}
+	case reflect.Map, reflect.Interface:
+		header = csvMapKeys(rows)
+		record = func(row reflect.Value) ([]string, error) {
+			row = indirect(row)
+			rec := make([]string, len(header))
+			if !row.IsValid() || row.Kind() != reflect.Map {
+				return rec, nil
+			}
+			for i, key := range header {
+				cell, err := csvCell(row.MapIndex(reflect.ValueOf(key)))
+				if err != nil {
+					return nil, err
+				}
+				rec[i] = cell
+			}
+			return rec, nil
+		}
+	default:
+		header = []string{"value"}
+		record = func(row reflect.Value) ([]string, error) {
+			cell, err := csvCell(row)
+			return []string{cell}, err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for i := 0; i < rows.Len(); i++ {
+		rec, err := record(rows.Index(i))
+		if err != nil {
+			return err
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvRows returns list of rows held by v
+func csvRows(v reflect.Value) (reflect.Value, bool) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return reflect.Value{}, false
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return reflect.Value{}, false
+		}
+		return v, true
+	case reflect.Struct:
+		results := v.FieldByName(csvResultsField)
+		if !results.IsValid() {
+			return reflect.Value{}, false
+		}
+		return csvRows(results)
+	}
+
+	return reflect.Value{}, false
+}
+
+// csvMapKeys returns sorted union of string keys of map rows
+func csvMapKeys(rows reflect.Value) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for i := 0; i < rows.Len(); i++ {
+		row := indirect(rows.Index(i))
+		if !row.IsValid() || row.Kind() != reflect.Map || row.Type().Key().Kind() != reflect.String {
+			continue
+		}
+		for _, k := range row.MapKeys() {
+			if key := k.String(); !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func csvCell(v reflect.Value) (string, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return "", nil
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+
+	// nested values are kept as JSON
+	payload, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields returns fields as encoding/json would, inlining untagged embedded structs
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, sf := range structFields(ft) {
+					sf.index = append([]int{i}, sf.index...)
+					fields = append(fields, sf)
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields = append(fields, structField{name: name, index: []int{i}})
+	}
+	return fields
+}
+
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, idx := range index {
+		if i > 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(idx)
+	}
+	return v, true
+}
@@ -0,0 +1,156 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/rovergulf/utils/pgxs"
+	"net/http"
+)
+
+// Error is a domain error carrying registered error code.
+// Errors match each other with errors.Is if their codes are equal,
+// so package level errors may be used as sentinels:
+//
+//	var ErrUserNotFound = response.NewError(CodeUserNotFound, "user not found")
+//	...
+//	return response.Wrap(err, CodeUserNotFound, "")
+//	...
+//	errors.Is(err, ErrUserNotFound) // true
+type Error struct {
+	Code    int
+	Message string
+	// Err is an underlying cause, it is never exposed to clients
+	Err error
+}
+
+// NewError returns Error with code, registered default message is used if msg is empty
+func NewError(code int, msg string) *Error {
+	return &Error{Code: code, Message: msg}
+}
+
+// Wrap returns Error with code caused by err
+func Wrap(err error, code int, msg string) *Error {
+	return &Error{Code: code, Message: msg, Err: err}
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = DefaultRegistry.errorCode(e.Code).Message
+	}
+
+	if e.Err != nil {
+		if msg == "" {
+			return e.Err.Error()
+		}
+		return msg + ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// errorCodes maps well known errors to internal codes
+var errorCodes = []struct {
+	err  error
+	code int
+}{
+	{pgxs.ErrNotExist, ErrCodeNotFound},
+	{pgx.ErrNoRows, ErrCodeNotFound},
+	{pgxs.ErrAlreadyExist, ErrCodeAlreadyExists},
+	{context.DeadlineExceeded, ErrCodeDeadlineExceeded},
+}
+
+// CodeOf returns internal code of err, ErrCodeUnknown if err has none
+func CodeOf(err error) int {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode
+	}
+
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	return ErrCodeUnknown
+}
+
+// GRPCCodeOf returns gRPC status code of err
+func GRPCCodeOf(err error) GRPCCode {
+	if err == nil {
+		return GRPCOK
+	}
+	return DefaultRegistry.errorCode(CodeOf(err)).GRPCCode
+}
+
+// IsRetryable reports whether err is of retryable error code
+func IsRetryable(err error) bool {
+	return err != nil && DefaultRegistry.errorCode(CodeOf(err)).Retryable
+}
+
+// FromError converts err into ApiError using DefaultRegistry
+func FromError(err error) *ApiError {
+	return DefaultRegistry.FromError(err)
+}
+
+// FromError converts err into ApiError.
+// A copy of ApiError found in err chain is returned, so package level errors are not
+// modified by callers filling request details. Status and message of domain Error and
+// well known errors such as pgx.ErrNoRows are resolved by registered code.
+// Others result in 500 without details, so internal error messages are not exposed to clients.
+func (r *Registry) FromError(err error) *ApiError {
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr.Copy()
+	}
+
+	var apiErrValue ApiError
+	if errors.As(err, &apiErrValue) {
+		return &apiErrValue
+	}
+
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return r.newApiError(domainErr.Code, domainErr.Message)
+	}
+
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return r.newApiError(c.code, "")
+		}
+	}
+
+	return NewProblem(http.StatusInternalServerError, ErrCodeUnknown, "")
+}
+
+func (r *Registry) newApiError(code int, msg string) *ApiError {
+	c := r.errorCode(code)
+	if msg == "" {
+		msg = c.Message
+	}
+
+	apiErr := NewProblem(c.HttpStatus, code, msg)
+	apiErr.Retryable = c.Retryable
+	return apiErr
+}
+
+// WriteError responds with problem details of err resolved by DefaultRegistry
+func WriteError(w http.ResponseWriter, err error) error {
+	return FromError(err).Write(w)
+}
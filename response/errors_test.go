@@ -0,0 +1,134 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/rovergulf/utils/pgxs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   int
+	}{
+		{pgxs.ErrNotExist, http.StatusNotFound, ErrCodeNotFound},
+		{fmt.Errorf("get user: %w", pgx.ErrNoRows), http.StatusNotFound, ErrCodeNotFound},
+		{pgxs.ErrAlreadyExist, http.StatusConflict, ErrCodeAlreadyExists},
+		{fmt.Errorf("wrapped: %w", NewProblem(http.StatusTeapot, 42, "brew")), http.StatusTeapot, 42},
+		{fmt.Errorf("boom"), http.StatusInternalServerError, ErrCodeUnknown},
+	}
+
+	for _, tt := range tests {
+		apiErr := FromError(tt.err)
+		if apiErr.HttpStatus != tt.status || apiErr.ErrorCode != tt.code {
+			t.Errorf("FromError(%v) = %d/%d, want %d/%d", tt.err, apiErr.HttpStatus, apiErr.ErrorCode, tt.status, tt.code)
+		}
+	}
+
+	if apiErr := FromError(fmt.Errorf("secret")); apiErr.Detail != "" {
+		t.Errorf("internal error detail is exposed: %q", apiErr.Detail)
+	}
+
+	wrapped := fmt.Errorf("select * from users where id = 42: %w", pgx.ErrNoRows)
+	if apiErr := FromError(wrapped); apiErr.Detail != DefaultRegistry.errorCode(ErrCodeNotFound).Message {
+		t.Errorf("well known error detail = %q, want registered message", apiErr.Detail)
+	}
+}
+
+func TestFromError_Copy(t *testing.T) {
+	errSentinel := NewProblem(http.StatusConflict, ErrCodeAlreadyExists, "already exists")
+	errSentinel.Errors = []FieldError{{Field: "email", Message: "taken"}}
+
+	apiErr := FromError(fmt.Errorf("create: %w", errSentinel))
+	if apiErr == errSentinel {
+		t.Fatal("FromError() returned the error from chain instead of a copy")
+	}
+	apiErr.Instance = "/users"
+	apiErr.Errors[0].Message = "changed"
+
+	if errSentinel.Instance != "" || errSentinel.Errors[0].Message != "taken" {
+		t.Errorf("error in chain is modified: %+v", errSentinel)
+	}
+
+	sentinel := &ApiError{HttpStatus: http.StatusNotFound}
+	for i := 0; i < 2; i++ {
+		if err := sentinel.Write(httptest.NewRecorder()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sentinel.Title != "" {
+		t.Errorf("Write() modified error title = %q", sentinel.Title)
+	}
+}
+
+func TestApiError_Write(t *testing.T) {
+	w := httptest.NewRecorder()
+	apiErr := &ApiError{HttpStatus: http.StatusNotFound, Detail: "no such user", Errors: []FieldError{{Field: "id", Message: "unknown"}}}
+	if err := apiErr.Write(w); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if got := w.Header().Get("Content-Type"); got != ContentTypeProblemJSON {
+		t.Errorf("Content-Type = %s, want %s", got, ContentTypeProblemJSON)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []string{"title", "status", "detail", "code", "errors"} {
+		if _, ok := body[member]; !ok {
+			t.Errorf("member %q is missing in %s", member, w.Body.String())
+		}
+	}
+	if body["title"] != "Not Found" {
+		t.Errorf("title = %v, want Not Found", body["title"])
+	}
+}
+
+const testCodeQuotaExceeded = 1000
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	code := ErrorCode{testCodeQuotaExceeded, http.StatusPaymentRequired, GRPCResourceExhausted, "quota exceeded", true}
+	if err := r.Register(code); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(code); !errors.Is(err, ErrCodeRegistered) {
+		t.Errorf("Register() duplicate error = %v, want ErrCodeRegistered", err)
+	}
+
+	errQuota := NewError(testCodeQuotaExceeded, "")
+	err := fmt.Errorf("upload: %w", Wrap(fmt.Errorf("disk usage 101%%"), testCodeQuotaExceeded, ""))
+	if !errors.Is(err, errQuota) {
+		t.Errorf("errors.Is(%v, errQuota) = false", err)
+	}
+	if errors.Is(err, NewError(ErrCodeNotFound, "")) {
+		t.Errorf("errors.Is(%v, not found) = true", err)
+	}
+
+	apiErr := r.FromError(err)
+	if apiErr.HttpStatus != http.StatusPaymentRequired || apiErr.Detail != "quota exceeded" || !apiErr.Retryable {
+		t.Errorf("FromError() = %+v", apiErr)
+	}
+
+	if got := HttpCode(ErrCodeAlreadyExists); got != http.StatusConflict {
+		t.Errorf("HttpCode(ErrCodeAlreadyExists) = %d, want %d", got, http.StatusConflict)
+	}
+	if got := GRPCCodeOf(pgxs.ErrNotExist); got != GRPCNotFound {
+		t.Errorf("GRPCCodeOf(pgxs.ErrNotExist) = %d, want %d", got, GRPCNotFound)
+	}
+	if !IsRetryable(context.DeadlineExceeded) {
+		t.Errorf("IsRetryable(context.DeadlineExceeded) = false")
+	}
+}
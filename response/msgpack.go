@@ -0,0 +1,34 @@
+package response
+
+import (
+	"bytes"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+)
+
+// MsgPackEncoder encodes values in MessagePack format.
+// Struct fields are named after their json tags and respect omitempty, keys of
+// map[string]interface{} and map[string]string are sorted to keep output stable.
+// Times are encoded with MessagePack timestamp extension, types customize their
+// encoding by implementing msgpack.CustomEncoder or msgpack.Marshaler.
+var MsgPackEncoder = NewEncoder("application/msgpack", func(w io.Writer, v interface{}) error {
+	return newMsgPackEncoder(w).Encode(v)
+})
+
+// MarshalMsgPack returns MessagePack encoding of v
+func MarshalMsgPack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := MsgPackEncoder.Encode(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newMsgPackEncoder(w io.Writer) *msgpack.Encoder {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+	enc.UseCompactFloats(true)
+	return enc
+}
@@ -0,0 +1,171 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var ErrNotAcceptable = fmt.Errorf("none of the acceptable media types is supported")
+
+// Encoder writes values in a particular media type
+type Encoder interface {
+	// ContentType is a Content-Type header value of encoded response
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+}
+
+// Supporter is implemented by encoders able to encode only particular values,
+// such encoders are skipped by Negotiator for values they do not support
+type Supporter interface {
+	Supports(v interface{}) bool
+}
+
+type encoderFunc struct {
+	contentType string
+	encode      func(w io.Writer, v interface{}) error
+}
+
+func (e encoderFunc) ContentType() string {
+	return e.contentType
+}
+
+func (e encoderFunc) Encode(w io.Writer, v interface{}) error {
+	return e.encode(w, v)
+}
+
+// NewEncoder returns Encoder calling encode function
+func NewEncoder(contentType string, encode func(w io.Writer, v interface{}) error) Encoder {
+	return encoderFunc{contentType: contentType, encode: encode}
+}
+
+var (
+	JSONEncoder = NewEncoder("application/json; charset=utf-8", func(w io.Writer, v interface{}) error {
+		return json.NewEncoder(w).Encode(v)
+	})
+	YAMLEncoder = NewEncoder("application/yaml; charset=utf-8", func(w io.Writer, v interface{}) error {
+		payload, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(payload)
+		return err
+	})
+)
+
+// DefaultNegotiator supports JSON, YAML, MessagePack and CSV, JSON is used if client accepts anything
+var DefaultNegotiator = NewNegotiator(JSONEncoder, YAMLEncoder, MsgPackEncoder, CSVEncoder)
+
+// Negotiator selects response encoder by request Accept header.
+// If several encoders are equally acceptable, the one registered first is used.
+type Negotiator struct {
+	mx       sync.RWMutex
+	encoders []Encoder
+}
+
+func NewNegotiator(encoders ...Encoder) *Negotiator {
+	n := new(Negotiator)
+	for _, enc := range encoders {
+		n.Register(enc)
+	}
+	return n
+}
+
+// Register adds encoder, replacing already registered one for the same media type
+func (n *Negotiator) Register(enc Encoder) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	mediaType := encoderMediaType(enc)
+	for i := range n.encoders {
+		if encoderMediaType(n.encoders[i]) == mediaType {
+			n.encoders[i] = enc
+			return
+		}
+	}
+
+	n.encoders = append(n.encoders, enc)
+}
+
+// Negotiate returns the most acceptable encoder able to encode v
+func (n *Negotiator) Negotiate(accept string, v interface{}) (Encoder, error) {
+	n.mx.RLock()
+	defer n.mx.RUnlock()
+
+	ranges := ParseQualityValues(accept)
+	if len(ranges) == 0 {
+		ranges = []QualityValue{{Value: "*/*", Q: 1}}
+	}
+
+	var best Encoder
+	var bestQ float64
+	for _, enc := range n.encoders {
+		if s, ok := enc.(Supporter); ok && !s.Supports(v) {
+			continue
+		}
+
+		if q := acceptQuality(ranges, encoderMediaType(enc)); q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	if best == nil {
+		return nil, ErrNotAcceptable
+	}
+
+	return best, nil
+}
+
+// Write encodes v in the media type negotiated by request Accept header,
+// responding with 406 if none of acceptable media types is supported
+func (n *Negotiator) Write(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+	w.Header().Add("Vary", "Accept")
+
+	enc, err := n.Negotiate(r.Header.Get("Accept"), v)
+	if err != nil {
+		NewProblem(http.StatusNotAcceptable, ErrCodeUnknown, err.Error()).Write(w)
+		return err
+	}
+
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.WriteHeader(status)
+
+	return enc.Encode(w, v)
+}
+
+// acceptQuality returns quality of the most specific media range matching media type
+func acceptQuality(ranges []QualityValue, mediaType string) float64 {
+	q, specificity := 0.0, -1
+	for _, mr := range ranges {
+		mrType := mr.Value
+
+		s := -1
+		switch {
+		case mrType == mediaType:
+			s = 2
+		case mrType == "*/*":
+			s = 0
+		case strings.HasSuffix(mrType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mrType, "*")):
+			s = 1
+		}
+
+		if s > specificity {
+			q, specificity = mr.Q, s
+		}
+	}
+
+	return q
+}
+
+func encoderMediaType(enc Encoder) string {
+	mediaType, _, err := mime.ParseMediaType(enc.ContentType())
+	if err != nil {
+		return strings.ToLower(enc.ContentType())
+	}
+	return mediaType
+}
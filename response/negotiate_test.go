@@ -0,0 +1,153 @@
+package response
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/vmihailenco/msgpack/v5"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+	Note string `json:"-"`
+}
+
+type testList struct {
+	Results []testItem `json:"results"`
+	Count   int        `json:"count"`
+}
+
+func TestNegotiator_Negotiate(t *testing.T) {
+	list := testList{Results: []testItem{{ID: 1, Name: "a"}}}
+
+	tests := []struct {
+		accept string
+		v      interface{}
+		want   string
+	}{
+		{"", list, "application/json"},
+		{"*/*", list, "application/json"},
+		{"application/yaml", list, "application/yaml"},
+		{"text/html, application/msgpack;q=0.9, application/json;q=0.5", list, "application/msgpack"},
+		{"text/*", list, "text/csv"},
+		{"text/csv, application/json;q=0.1", map[string]string{"a": "b"}, "application/json"},
+		{"application/*;q=0.2, application/yaml;q=0", list, "application/json"},
+		{"text/html", list, ""},
+	}
+
+	for _, tt := range tests {
+		enc, err := DefaultNegotiator.Negotiate(tt.accept, tt.v)
+		if tt.want == "" {
+			if err != ErrNotAcceptable {
+				t.Errorf("Negotiate(%q) error = %v, want ErrNotAcceptable", tt.accept, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Negotiate(%q) unexpected error: %s", tt.accept, err)
+			continue
+		}
+		if got := encoderMediaType(enc); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiator_Write(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "image/png")
+	w := httptest.NewRecorder()
+
+	if err := DefaultNegotiator.Write(w, r, http.StatusOK, testList{}); err != ErrNotAcceptable {
+		t.Fatalf("Write() error = %v, want ErrNotAcceptable", err)
+	}
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotAcceptable)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Vary = %q, want Accept", got)
+	}
+}
+
+func TestCSVEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	list := &testList{Results: []testItem{{ID: 1, Name: "a,b"}, {ID: 2}}}
+	if err := CSVEncoder.Encode(&buf, list); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id,name\n1,\"a,b\"\n2,\n"
+	if buf.String() != want {
+		t.Errorf("CSV = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestMarshalMsgPack(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{-1, []byte{0xff}},
+		{300, []byte{0xcd, 0x01, 0x2c}},
+		{"hi", []byte{0xa2, 'h', 'i'}},
+		{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{testItem{ID: 1}, []byte{0x81, 0xa2, 'i', 'd', 0x01}},
+		{map[string]interface{}{"b": false, "a": true}, []byte{0x82, 0xa1, 'a', 0xc3, 0xa1, 'b', 0xc2}},
+	}
+
+	for _, tt := range tests {
+		got, err := MarshalMsgPack(tt.v)
+		if err != nil {
+			t.Errorf("MarshalMsgPack(%v) unexpected error: %s", tt.v, err)
+			continue
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("MarshalMsgPack(%v) = %x, want %x", tt.v, got, tt.want)
+		}
+	}
+}
+
+// testMoney encodes itself as a string of cents
+type testMoney int64
+
+func (m testMoney) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeString(fmt.Sprintf("%d.%02d", m/100, m%100))
+}
+
+func TestMarshalMsgPack_values(t *testing.T) {
+	type row struct {
+		ID    int64                  `json:"id"`
+		Price testMoney              `json:"price"`
+		Attrs map[string]interface{} `json:"attrs,omitempty"`
+		Skip  string                 `json:"-"`
+	}
+
+	data, err := MarshalMsgPack(row{ID: 1<<53 + 1, Price: 1999, Skip: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := msgpack.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// compact integers are decoded as uint64 or int64 depending on sign
+	if id := fmt.Sprint(got["id"]); id != "9007199254740993" {
+		t.Errorf("id = %s, want 9007199254740993", id)
+	}
+	if got["price"] != "19.99" {
+		t.Errorf("price = %v, want custom encoded 19.99", got["price"])
+	}
+	if _, ok := got["attrs"]; ok {
+		t.Errorf("empty attrs are not omitted: %v", got)
+	}
+	if _, ok := got["Skip"]; ok {
+		t.Errorf("skipped field is encoded: %v", got)
+	}
+}
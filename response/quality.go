@@ -0,0 +1,53 @@
+package response
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QualityValue is an element of the header with quality values, like Accept or Accept-Encoding
+type QualityValue struct {
+	Value string
+	Q     float64
+}
+
+// ParseQualityValues parses comma separated header values with optional "q" parameter,
+// returning them sorted by quality, highest first. Values with invalid q are ignored.
+func ParseQualityValues(header string) []QualityValue {
+	var values []QualityValue
+
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(params[0]))
+		if value == "" {
+			continue
+		}
+
+		qv := QualityValue{Value: value, Q: 1}
+		valid := true
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") && !strings.HasPrefix(param, "Q=") {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil || q < 0 || q > 1 {
+				valid = false
+				break
+			}
+			qv.Q = q
+		}
+
+		if valid {
+			values = append(values, qv)
+		}
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Q > values[j].Q
+	})
+
+	return values
+}
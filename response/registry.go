@@ -0,0 +1,140 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// GRPCCode is a gRPC status code, values match google.golang.org/grpc/codes.Code,
+// so it may be converted with codes.Code(c)
+type GRPCCode uint32
+
+const (
+	GRPCOK GRPCCode = iota
+	GRPCCanceled
+	GRPCUnknown
+	GRPCInvalidArgument
+	GRPCDeadlineExceeded
+	GRPCNotFound
+	GRPCAlreadyExists
+	GRPCPermissionDenied
+	GRPCResourceExhausted
+	GRPCFailedPrecondition
+	GRPCAborted
+	GRPCOutOfRange
+	GRPCUnimplemented
+	GRPCInternal
+	GRPCUnavailable
+	GRPCDataLoss
+	GRPCUnauthenticated
+)
+
+var ErrCodeRegistered = fmt.Errorf("error code is already registered")
+
+// ErrorCode describes how errors of the code are reported to clients
+type ErrorCode struct {
+	Code       int
+	HttpStatus int
+	GRPCCode   GRPCCode
+	// Message is a default message of errors created without one
+	Message string
+	// Retryable reports whether request failed with the error may be retried
+	Retryable bool
+}
+
+// Registry holds error codes declared by service
+type Registry struct {
+	mx    sync.RWMutex
+	codes map[int]ErrorCode
+}
+
+// DefaultRegistry contains built-in error codes, services register own codes there
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	DefaultRegistry.MustRegister(
+		ErrorCode{ErrCodeUnknown, http.StatusInternalServerError, GRPCUnknown, "internal error", false},
+		ErrorCode{ErrCodeInvalidArguments, http.StatusBadRequest, GRPCInvalidArgument, "invalid arguments", false},
+		ErrorCode{ErrCodeAlreadyExists, http.StatusConflict, GRPCAlreadyExists, "already exists", false},
+		ErrorCode{ErrCodeNotFound, http.StatusNotFound, GRPCNotFound, "not found", false},
+		ErrorCode{ErrCodeUnauthenticated, http.StatusUnauthorized, GRPCUnauthenticated, "unauthenticated", false},
+		ErrorCode{ErrCodePermissionDenied, http.StatusForbidden, GRPCPermissionDenied, "permission denied", false},
+		ErrorCode{ErrCodeTooManyRequests, http.StatusTooManyRequests, GRPCResourceExhausted, "too many requests", true},
+		ErrorCode{ErrCodeDeadlineExceeded, http.StatusGatewayTimeout, GRPCDeadlineExceeded, "deadline exceeded", true},
+		ErrorCode{ErrCodeUnavailable, http.StatusServiceUnavailable, GRPCUnavailable, "service unavailable", true},
+	)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{codes: make(map[int]ErrorCode)}
+}
+
+// Register adds error codes, it returns ErrCodeRegistered if any code is already registered
+func (r *Registry) Register(codes ...ErrorCode) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	for _, c := range codes {
+		if _, ok := r.codes[c.Code]; ok {
+			return fmt.Errorf("%w: %d", ErrCodeRegistered, c.Code)
+		}
+	}
+
+	for _, c := range codes {
+		r.codes[c.Code] = c
+	}
+
+	return nil
+}
+
+// MustRegister is like Register but panics if any code is already registered
+func (r *Registry) MustRegister(codes ...ErrorCode) {
+	if err := r.Register(codes...); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns registered error code
+func (r *Registry) Lookup(code int) (ErrorCode, bool) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	c, ok := r.codes[code]
+	return c, ok
+}
+
+// Codes returns every registered error code ordered by code
+func (r *Registry) Codes() []ErrorCode {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	codes := make([]ErrorCode, 0, len(r.codes))
+	for _, c := range r.codes {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].Code < codes[j].Code
+	})
+
+	return codes
+}
+
+// errorCode returns registered error code, unknown codes are reported as internal errors
+func (r *Registry) errorCode(code int) ErrorCode {
+	if c, ok := r.Lookup(code); ok {
+		return c
+	}
+
+	return ErrorCode{
+		Code:       code,
+		HttpStatus: http.StatusInternalServerError,
+		GRPCCode:   GRPCUnknown,
+	}
+}
+
+// Register adds error codes to DefaultRegistry
+func Register(codes ...ErrorCode) error {
+	return DefaultRegistry.Register(codes...)
+}
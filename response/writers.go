@@ -1,7 +1,6 @@
 package response
 
 import (
-	"bytes"
 	"encoding/json"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
@@ -9,19 +8,14 @@ import (
 )
 
 func Json(w io.Writer, lg *zap.SugaredLogger, v interface{}) error {
-	payload, err := json.Marshal(v)
-	if err != nil {
-		lg.Errorf("unable to marshal payload: %s", err)
-		return err
-	}
-
-	out := bytes.NewBuffer(nil)
-	if err := json.Indent(out, payload, "", "	"); err != nil {
-		lg.Errorf("Unable to indent json payload: %s", err)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "	")
+	if err := enc.Encode(v); err != nil {
+		lg.Errorf("unable to encode payload: %s", err)
 		return err
 	}
 
-	return write(w, out.Bytes())
+	return nil
 }
 
 func Yaml(w io.Writer, lg *zap.SugaredLogger, v interface{}) error {
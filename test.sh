@@ -2,6 +2,6 @@
 
 set -e
 
-for testPath in "./colors" "./pgxs" "./useragent" "./ipaddr"; do
+for testPath in "./colors" "./pgxs" "./useragent" "./ipaddr" "./httplib" "./tracing" "./response"; do
   go test $testPath
 done
@@ -36,12 +36,16 @@ func NewJaeger(ctx context.Context, logger *zap.SugaredLogger, serviceName, addr
 		return nil, err
 	}
 
+	propagator := NewHTTPPropagator()
+
 	tracer, closer, err := config.Configuration{
 		ServiceName: j.ServiceName,
 	}.NewTracer(
 		config.Sampler(jaeger.NewConstSampler(true)),
 		config.Reporter(jaeger.NewRemoteReporter(traceTransport, jaeger.ReporterOptions.Logger(jaeger.StdLogger))),
 		config.Metrics(j.Metrics),
+		config.Injector(opentracing.HTTPHeaders, propagator),
+		config.Extractor(opentracing.HTTPHeaders, propagator),
 	)
 	if err != nil {
 		j.logger.Errorf("Unable to start tracer: %s", err)
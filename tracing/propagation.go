@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"fmt"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/zipkin"
+	"strconv"
+	"strings"
+)
+
+// W3C trace context header names reference:
+// https://www.w3.org/TR/trace-context/#traceparent-header
+const (
+	TraceParentHeader  = "traceparent"
+	traceParentVersion = "00"
+	traceFlagSampled   = 0x01
+)
+
+// W3CPropagator injects and extracts span context using W3C traceparent header
+type W3CPropagator struct{}
+
+// Inject conforms to the jaeger.Injector interface
+func (p W3CPropagator) Inject(sc jaeger.SpanContext, carrier interface{}) error {
+	w, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	var flags byte
+	if sc.IsSampled() {
+		flags |= traceFlagSampled
+	}
+
+	traceID := sc.TraceID()
+	w.Set(TraceParentHeader, fmt.Sprintf("%s-%016x%016x-%016x-%02x",
+		traceParentVersion, traceID.High, traceID.Low, uint64(sc.SpanID()), flags))
+
+	return nil
+}
+
+// Extract conforms to the jaeger.Extractor interface
+func (p W3CPropagator) Extract(carrier interface{}) (jaeger.SpanContext, error) {
+	r, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return jaeger.SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+
+	var traceParent string
+	if err := r.ForeachKey(func(key, val string) error {
+		if strings.EqualFold(key, TraceParentHeader) {
+			traceParent = val
+		}
+		return nil
+	}); err != nil {
+		return jaeger.SpanContext{}, err
+	}
+
+	if traceParent == "" {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextNotFound
+	}
+
+	return parseTraceParent(traceParent)
+}
+
+func parseTraceParent(v string) (jaeger.SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	// version ff is forbidden, future versions may only append fields
+	if parts[0] == "ff" || (parts[0] == traceParentVersion && len(parts) != 4) {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	traceID, err := jaeger.TraceIDFromString(parts[1])
+	if err != nil || !traceID.IsValid() {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	spanID, err := strconv.ParseUint(parts[2], 16, 64)
+	if err != nil || spanID == 0 {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextCorrupted
+	}
+
+	return jaeger.NewSpanContext(traceID, jaeger.SpanID(spanID), 0, flags&traceFlagSampled != 0, nil), nil
+}
+
+// HTTPPropagator extracts span context from Jaeger uber-trace-id, W3C traceparent
+// or Zipkin B3 headers, whichever is found first, and injects it in all of these formats
+type HTTPPropagator struct {
+	propagators []propagator
+}
+
+type propagator interface {
+	jaeger.Injector
+	jaeger.Extractor
+}
+
+// NewHTTPPropagator returns propagator for opentracing.HTTPHeaders format
+func NewHTTPPropagator() *HTTPPropagator {
+	headers := new(jaeger.HeadersConfig).ApplyDefaults()
+	return &HTTPPropagator{
+		propagators: []propagator{
+			jaeger.NewHTTPHeaderPropagator(headers, *jaeger.NewNullMetrics()),
+			W3CPropagator{},
+			zipkin.NewZipkinB3HTTPHeaderPropagator(),
+		},
+	}
+}
+
+// Inject conforms to the jaeger.Injector interface
+func (p *HTTPPropagator) Inject(sc jaeger.SpanContext, carrier interface{}) error {
+	for _, prop := range p.propagators {
+		if err := prop.Inject(sc, carrier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extract conforms to the jaeger.Extractor interface
+func (p *HTTPPropagator) Extract(carrier interface{}) (jaeger.SpanContext, error) {
+	err := opentracing.ErrSpanContextNotFound
+	for _, prop := range p.propagators {
+		sc, extractErr := prop.Extract(carrier)
+		if extractErr == nil && sc.TraceID().IsValid() {
+			return sc, nil
+		}
+
+		if extractErr != nil && extractErr != opentracing.ErrSpanContextNotFound {
+			err = extractErr
+		}
+	}
+
+	return jaeger.SpanContext{}, err
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPPropagator_Extract(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		traceID string
+		spanID  uint64
+		sampled bool
+		wantErr error
+	}{
+		{
+			name:    "jaeger",
+			headers: map[string]string{"uber-trace-id": "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1"},
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  0x00f067aa0ba902b7,
+			sampled: true,
+		},
+		{
+			name:    "w3c",
+			headers: map[string]string{"Traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  0x00f067aa0ba902b7,
+		},
+		{
+			name: "b3",
+			headers: map[string]string{
+				"X-B3-TraceId": "a3ce929d0e0e4736",
+				"X-B3-SpanId":  "00f067aa0ba902b7",
+				"X-B3-Sampled": "1",
+			},
+			traceID: "a3ce929d0e0e4736",
+			spanID:  0x00f067aa0ba902b7,
+			sampled: true,
+		},
+		{
+			name:    "corrupted w3c",
+			headers: map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+			wantErr: opentracing.ErrSpanContextCorrupted,
+		},
+		{
+			name:    "none",
+			headers: map[string]string{"X-Request-ID": "1"},
+			wantErr: opentracing.ErrSpanContextNotFound,
+		},
+	}
+
+	p := NewHTTPPropagator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := make(http.Header)
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+
+			sc, err := p.Extract(opentracing.HTTPHeadersCarrier(h))
+			if err != tt.wantErr {
+				t.Fatalf("Extract() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := sc.TraceID().String(); got != tt.traceID {
+				t.Errorf("trace id = %s, want %s", got, tt.traceID)
+			}
+			if got := uint64(sc.SpanID()); got != tt.spanID {
+				t.Errorf("span id = %x, want %x", got, tt.spanID)
+			}
+			if sc.IsSampled() != tt.sampled {
+				t.Errorf("sampled = %v, want %v", sc.IsSampled(), tt.sampled)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package ua
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// BotCategory groups bots which are usually treated the same way
+type BotCategory string
+
+// Bot categories of default rules
+const (
+	BotSearchEngine    BotCategory = "search_engine"
+	BotSocial          BotCategory = "social"
+	BotAICrawler       BotCategory = "ai_crawler"
+	BotSEO             BotCategory = "seo"
+	BotMonitoring      BotCategory = "monitoring"
+	BotHeadlessBrowser BotCategory = "headless_browser"
+	BotHTTPLibrary     BotCategory = "http_library"
+	// BotOther is a category of bots found by Parse heuristics, but not matched by any rule
+	BotOther BotCategory = "other"
+)
+
+//go:embed bots.json
+var defaultBotRulesData []byte
+
+// BotRule matches bot user agent either by Contains substring, compared case-insensitively,
+// or by Pattern regular expression
+type BotRule struct {
+	Name     string      `json:"name"`
+	Category BotCategory `json:"category"`
+	Contains string      `json:"contains,omitempty"`
+	Pattern  string      `json:"pattern,omitempty"`
+}
+
+// BotInfo is a detected bot name and category
+type BotInfo struct {
+	Name     string      `json:"name"`
+	Category BotCategory `json:"category"`
+}
+
+type compiledBotRule struct {
+	info     BotInfo
+	contains string
+	pattern  *regexp.Regexp
+}
+
+// BotRules is a compiled bot rules set, rules are matched in order they are listed
+type BotRules struct {
+	rules []compiledBotRule
+}
+
+// NewBotRules compiles rules, each rule must have name, category and either substring or pattern
+func NewBotRules(rules []BotRule) (*BotRules, error) {
+	br := &BotRules{rules: make([]compiledBotRule, 0, len(rules))}
+
+	for i, rule := range rules {
+		if rule.Name == "" || rule.Category == "" {
+			return nil, fmt.Errorf("ua: bot rule %d: name and category are required", i)
+		}
+		if (rule.Contains == "") == (rule.Pattern == "") {
+			return nil, fmt.Errorf("ua: bot rule %q: exactly one of contains or pattern is required", rule.Name)
+		}
+
+		compiled := compiledBotRule{
+			info:     BotInfo{Name: rule.Name, Category: rule.Category},
+			contains: strings.ToLower(rule.Contains),
+		}
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("ua: bot rule %q: %w", rule.Name, err)
+			}
+			compiled.pattern = re
+		}
+
+		br.rules = append(br.rules, compiled)
+	}
+
+	return br, nil
+}
+
+// ParseBotRules compiles JSON encoded list of rules
+func ParseBotRules(data []byte) (*BotRules, error) {
+	var rules []BotRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("ua: invalid bot rules: %w", err)
+	}
+	return NewBotRules(rules)
+}
+
+// LoadBotRules reads and compiles JSON encoded list of rules from file
+func LoadBotRules(path string) (*BotRules, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBotRules(data)
+}
+
+// Detect returns the first rule matching user agent string
+func (br *BotRules) Detect(userAgent string) (BotInfo, bool) {
+	if br == nil || userAgent == "" {
+		return BotInfo{}, false
+	}
+
+	lower := strings.ToLower(userAgent)
+	for _, rule := range br.rules {
+		if rule.pattern != nil {
+			if rule.pattern.MatchString(userAgent) {
+				return rule.info, true
+			}
+		} else if strings.Contains(lower, rule.contains) {
+			return rule.info, true
+		}
+	}
+
+	return BotInfo{}, false
+}
+
+// Len returns number of rules
+func (br *BotRules) Len() int {
+	if br == nil {
+		return 0
+	}
+	return len(br.rules)
+}
+
+var botRules atomic.Value
+
+func init() {
+	botRules.Store(DefaultBotRules())
+}
+
+// DefaultBotRules returns rules embedded into the package, rules are compiled on each call
+func DefaultBotRules() *BotRules {
+	rules, err := ParseBotRules(defaultBotRulesData)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// SetBotRules replaces rules used by Parse and DetectBot, embedded rules are restored if rules is nil
+func SetBotRules(rules *BotRules) {
+	if rules == nil {
+		rules = DefaultBotRules()
+	}
+	botRules.Store(rules)
+}
+
+// DetectBot matches user agent string against rules set by SetBotRules, embedded ones by default
+func DetectBot(userAgent string) (BotInfo, bool) {
+	return botRules.Load().(*BotRules).Detect(userAgent)
+}
@@ -0,0 +1,112 @@
+package ua_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	ua "github.com/rovergulf/utils/useragent"
+)
+
+func TestDetectBot(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      ua.BotInfo
+		bot       bool
+	}{
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", ua.BotInfo{Name: "Googlebot", Category: ua.BotSearchEngine}, true},
+		{"Mozilla/5.0 (compatible; YandexBot/3.0; +http://yandex.com/bots)", ua.BotInfo{Name: "YandexBot", Category: ua.BotSearchEngine}, true},
+		{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.1; +https://openai.com/gptbot)", ua.BotInfo{Name: "GPTBot", Category: ua.BotAICrawler}, true},
+		{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; ClaudeBot/1.0; +claudebot@anthropic.com)", ua.BotInfo{Name: "ClaudeBot", Category: ua.BotAICrawler}, true},
+		{"Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)", ua.BotInfo{Name: "AhrefsBot", Category: ua.BotSEO}, true},
+		{"Mozilla/5.0 (compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", ua.BotInfo{Name: "UptimeRobot", Category: ua.BotMonitoring}, true},
+		{"kube-probe/1.27", ua.BotInfo{Name: "kube-probe", Category: ua.BotMonitoring}, true},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/119.0.6045.105 Safari/537.36", ua.BotInfo{Name: "HeadlessChrome", Category: ua.BotHeadlessBrowser}, true},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", ua.BotInfo{Name: "facebookexternalhit", Category: ua.BotSocial}, true},
+		{"curl/7.64.1", ua.BotInfo{Name: "curl", Category: ua.BotHTTPLibrary}, true},
+		{"python-requests/2.31.0", ua.BotInfo{Name: "python-requests", Category: ua.BotHTTPLibrary}, true},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36", ua.BotInfo{}, false},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", ua.BotInfo{}, false},
+		{"", ua.BotInfo{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ua.DetectBot(tt.userAgent)
+		if ok != tt.bot || got != tt.want {
+			t.Errorf("DetectBot(%q) = %+v, %v, want %+v, %v", tt.userAgent, got, ok, tt.want, tt.bot)
+		}
+	}
+}
+
+func TestParseBot(t *testing.T) {
+	agent := ua.Parse("Mozilla/5.0 (compatible; SemrushBot/7~bl; +http://www.semrush.com/bot.html)")
+	if !agent.Bot || agent.BotName != "SemrushBot" || agent.BotCategory != ua.BotSEO {
+		t.Errorf("unexpected bot %v %q %q", agent.Bot, agent.BotName, agent.BotCategory)
+	}
+
+	// bots found by heuristics only
+	agent = ua.Parse("BUbiNG (+http://law.di.unimi.it/BUbiNG.html)")
+	if !agent.Bot || agent.BotName != agent.Name || agent.BotCategory != ua.BotOther {
+		t.Errorf("unexpected bot %v %q %q", agent.Bot, agent.BotName, agent.BotCategory)
+	}
+
+	agent = ua.Parse("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15")
+	if agent.Bot || agent.BotName != "" || agent.BotCategory != "" {
+		t.Errorf("browser detected as bot %q %q", agent.BotName, agent.BotCategory)
+	}
+}
+
+func TestLoadBotRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.json")
+	data := `[
+		{"name": "Internal crawler", "category": "other", "contains": "acme-crawler"},
+		{"name": "Acme monitor", "category": "monitoring", "pattern": "^AcmeMonitor/\\d+"}
+	]`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := ua.LoadBotRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.Len() != 2 {
+		t.Fatalf("expected 2 rules, got %d", rules.Len())
+	}
+
+	if bot, ok := rules.Detect("Mozilla/5.0 (compatible; ACME-Crawler/2.0)"); !ok || bot.Name != "Internal crawler" {
+		t.Errorf("substring should match case-insensitively, got %+v %v", bot, ok)
+	}
+	if _, ok := rules.Detect("Mozilla/5.0 (compatible; Googlebot/2.1)"); ok {
+		t.Error("loaded rules should not include default ones")
+	}
+
+	ua.SetBotRules(rules)
+	defer ua.SetBotRules(nil)
+
+	agent := ua.Parse("AcmeMonitor/3 (+https://acme.example)")
+	if !agent.Bot || agent.BotName != "Acme monitor" || agent.BotCategory != ua.BotMonitoring {
+		t.Errorf("Parse should use rules set, got %v %q %q", agent.Bot, agent.BotName, agent.BotCategory)
+	}
+
+	ua.SetBotRules(nil)
+	if bot, ok := ua.DetectBot("curl/8.0.1"); !ok || bot.Name != "curl" {
+		t.Errorf("default rules should be restored, got %+v %v", bot, ok)
+	}
+}
+
+func TestParseBotRulesInvalid(t *testing.T) {
+	invalid := []string{
+		`{"name": "not a list"}`,
+		`[{"category": "seo", "contains": "x"}]`,
+		`[{"name": "both", "category": "seo", "contains": "x", "pattern": "y"}]`,
+		`[{"name": "none", "category": "seo"}]`,
+		`[{"name": "regexp", "category": "seo", "pattern": "(unclosed"}]`,
+	}
+
+	for _, data := range invalid {
+		if _, err := ua.ParseBotRules([]byte(data)); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package ua
+
+import (
+	"net/http"
+	"strings"
+)
+
+// User-Agent client hints headers
+const (
+	HeaderUserAgent              = "User-Agent"
+	HeaderSecCHUA                = "Sec-CH-UA"
+	HeaderSecCHUAMobile          = "Sec-CH-UA-Mobile"
+	HeaderSecCHUAPlatform        = "Sec-CH-UA-Platform"
+	HeaderSecCHUAPlatformVersion = "Sec-CH-UA-Platform-Version"
+	HeaderSecCHUAFullVersionList = "Sec-CH-UA-Full-Version-List"
+	HeaderSecCHUAModel           = "Sec-CH-UA-Model"
+	HeaderAcceptCH               = "Accept-CH"
+
+	brandChromium          = "Chromium"
+	clientHintVersionParam = "v"
+	clientHintTrue         = "?1"
+	clientHintFalse        = "?0"
+)
+
+// DefaultClientHints are hints requested by AcceptCH if none are specified
+var DefaultClientHints = []string{
+	HeaderSecCHUA,
+	HeaderSecCHUAMobile,
+	HeaderSecCHUAPlatform,
+	HeaderSecCHUAPlatformVersion,
+	HeaderSecCHUAFullVersionList,
+	HeaderSecCHUAModel,
+}
+
+// brands maps Sec-CH-UA brands to browser names, Chromium is used only if no other brand is known
+var brands = map[string]string{
+	"Google Chrome":    Chrome,
+	"Microsoft Edge":   Edge,
+	"Opera":            Opera,
+	"Vivaldi":          Vivaldi,
+	"Samsung Internet": "Samsung Browser",
+	"Brave":            "Brave",
+	"Yandex":           "YaBrowser",
+	brandChromium:      Chrome,
+}
+
+// platforms maps Sec-CH-UA-Platform values to OS names
+var platforms = map[string]string{
+	"Windows": Windows,
+	"macOS":   MacOS,
+	"Android": Android,
+	"iOS":     IOS,
+	"Linux":   Linux,
+}
+
+// ParseRequest parses request User-Agent header, refining it with client hints if browser sent them.
+// Hints take precedence, since browsers reduce User-Agent string to a frozen one.
+func ParseRequest(r *http.Request) UserAgent {
+	ua := Parse(r.Header.Get(HeaderUserAgent))
+
+	if name, version := brandFromHints(r.Header.Get(HeaderSecCHUA)); name != "" {
+		if ua.Name != name {
+			ua.Version = version
+		}
+		ua.Name = name
+
+		if _, fullVersion := brandFromHints(r.Header.Get(HeaderSecCHUAFullVersionList)); fullVersion != "" {
+			ua.Version = fullVersion
+		}
+	}
+
+	if platform := unquote(r.Header.Get(HeaderSecCHUAPlatform)); platform != "" {
+		if os, ok := platforms[platform]; ok {
+			platform = os
+		}
+		if ua.OS != platform {
+			ua.OSVersion = ""
+		}
+		ua.OS = platform
+
+		if version := unquote(r.Header.Get(HeaderSecCHUAPlatformVersion)); version != "" {
+			ua.OSVersion = version
+		}
+	}
+
+	if model := unquote(r.Header.Get(HeaderSecCHUAModel)); model != "" {
+		ua.Device = model
+	}
+
+	switch strings.TrimSpace(r.Header.Get(HeaderSecCHUAMobile)) {
+	case clientHintTrue:
+		ua.Mobile, ua.Tablet, ua.Desktop = true, false, false
+	case clientHintFalse:
+		ua.Mobile = false
+		ua.Desktop = !ua.Tablet && (ua.OS == Windows || ua.OS == MacOS || ua.OS == Linux || ua.OS == "Chrome OS")
+	}
+
+	return ua
+}
+
+// brandFromHints returns the most specific known brand of Sec-CH-UA
+// or Sec-CH-UA-Full-Version-List header and its version.
+// GREASE brands, such as "Not;A=Brand", are ignored.
+func brandFromHints(header string) (name, version string) {
+	for _, item := range splitQuoted(header, ',') {
+		params := splitQuoted(item, ';')
+		brand := unquote(params[0])
+
+		known, ok := brands[brand]
+		if !ok {
+			continue
+		}
+
+		name, version = known, ""
+		for _, p := range params[1:] {
+			kv := strings.SplitN(p, "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == clientHintVersionParam {
+				version = unquote(kv[1])
+			}
+		}
+
+		if brand != brandChromium {
+			return name, version
+		}
+	}
+
+	return name, version
+}
+
+// AcceptCH returns middleware asking browsers to send specified client hints, DefaultClientHints if none specified
+func AcceptCH(hints ...string) func(http.Handler) http.Handler {
+	if len(hints) == 0 {
+		hints = DefaultClientHints
+	}
+	value := strings.Join(hints, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(HeaderAcceptCH, value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `"`)
+}
+
+// splitQuoted splits s by sep not enclosed in double quotes
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	var quoted bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
@@ -0,0 +1,75 @@
+package ua_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ua "github.com/rovergulf/utils/useragent"
+)
+
+const frozenChromeAndroid = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/104.0.0.0 Mobile Safari/537.36"
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    ua.UserAgent
+	}{
+		{
+			name: "frozen user agent only",
+			headers: map[string]string{
+				ua.HeaderUserAgent: frozenChromeAndroid,
+			},
+			want: ua.UserAgent{Name: ua.Chrome, Version: "104.0.0.0", OS: ua.Android, OSVersion: "10", Mobile: true},
+		},
+		{
+			name: "full hints",
+			headers: map[string]string{
+				ua.HeaderUserAgent:              frozenChromeAndroid,
+				ua.HeaderSecCHUA:                `"Chromium";v="104", " Not A;Brand";v="99", "Google Chrome";v="104"`,
+				ua.HeaderSecCHUAFullVersionList: `"Chromium";v="104.0.5112.97", " Not A;Brand";v="99.0.0.0", "Google Chrome";v="104.0.5112.97"`,
+				ua.HeaderSecCHUAMobile:          "?1",
+				ua.HeaderSecCHUAPlatform:        `"Android"`,
+				ua.HeaderSecCHUAPlatformVersion: `"12.0.0"`,
+				ua.HeaderSecCHUAModel:           `"Pixel 6"`,
+			},
+			want: ua.UserAgent{Name: ua.Chrome, Version: "104.0.5112.97", OS: ua.Android, OSVersion: "12.0.0", Device: "Pixel 6", Mobile: true},
+		},
+		{
+			name: "edge on windows",
+			headers: map[string]string{
+				ua.HeaderUserAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/104.0.0.0 Safari/537.36",
+				ua.HeaderSecCHUA:         `"Chromium";v="104", "Not;A=Brand";v="24", "Microsoft Edge";v="104"`,
+				ua.HeaderSecCHUAMobile:   "?0",
+				ua.HeaderSecCHUAPlatform: `"Windows"`,
+			},
+			want: ua.UserAgent{Name: ua.Edge, Version: "104", OS: ua.Windows, OSVersion: "10.0", Desktop: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			got := ua.ParseRequest(r)
+			got.String = ""
+			if got != tt.want {
+				t.Errorf("ParseRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptCH(t *testing.T) {
+	handler := ua.AcceptCH(ua.HeaderSecCHUAModel)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := w.Header().Get(ua.HeaderAcceptCH); got != ua.HeaderSecCHUAModel {
+		t.Errorf("Accept-CH = %q, want %q", got, ua.HeaderSecCHUAModel)
+	}
+}
@@ -17,8 +17,12 @@ type UserAgent struct {
 	Tablet    bool
 	Desktop   bool
 	Bot       bool
-	URL       string
-	String    string
+	// BotName and BotCategory are set by matching bot rule, see SetBotRules.
+	// Bots found by heuristics only have Name as BotName and BotOther category
+	BotName     string
+	BotCategory BotCategory
+	URL         string
+	String      string
 }
 
 var ignore = map[string]struct{}{
@@ -271,6 +275,15 @@ func Parse(userAgent string) UserAgent {
 		}
 	}
 
+	if bot, ok := DetectBot(userAgent); ok {
+		ua.Bot = true
+		ua.BotName = bot.Name
+		ua.BotCategory = bot.Category
+	} else if ua.Bot {
+		ua.BotName = ua.Name
+		ua.BotCategory = BotOther
+	}
+
 	return ua
 }
 
@@ -6,7 +6,7 @@ import (
 )
 
 var (
-	emailRegex         = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]$`)
+	emailRegex         = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`)
 	phoneRegex         = regexp.MustCompile(`^\+\d{11,15}$`)
 	fullShortlinkRegex = regexp.MustCompile(`([a-zA-Z0-9]+[_-]?){1,256}$`)
 	shortlinkRegex     = regexp.MustCompile(`([a-zA-Z0-9]+[_-]?){5,256}$`)